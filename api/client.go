@@ -3,10 +3,14 @@ package api
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/outlyerapp/outlyer-cli/config"
+	"github.com/outlyerapp/outlyer-cli/credentials"
 )
 
 // Response represents an Outlyer API response when HTTP response
@@ -16,39 +20,46 @@ type Response struct {
 	ErrorDetail error
 }
 
-// Get will set the API token and default headers before issuing a GET request to Outlyer API
-func Get(endpoint string) ([]byte, error) {
-	baseURL := config.CLI.GetString("api-url")
-	completeURL := baseURL + endpoint
+// HTTPError is returned when the Outlyer API responds with an HTTP error code
+type HTTPError struct {
+	Code    int
+	Body    []byte
+	Message string
+}
 
-	client := http.Client{}
-	req, err := http.NewRequest("GET", completeURL, nil)
-	if err != nil {
-		return nil, err
+func (e *HTTPError) Error() string {
+	if e.Message != "" {
+		return e.Message
 	}
+	return string(bytes.TrimSpace(e.Body))
+}
 
-	// Add request headers
-	token := config.CLI.GetString("api-token")
-	req.Header.Add(http.CanonicalHeaderKey("Authorization"), fmt.Sprintf("Bearer %s", token))
-	req.Header.Add(http.CanonicalHeaderKey("Content-Type"), config.CLI.GetString("headers.post.content-type"))
-	commonHeaders := config.CLI.GetStringMapString("headers.common")
-	for k, v := range commonHeaders {
-		req.Header.Add(http.CanonicalHeaderKey(k), v)
-	}
+// IsNotFound checks whether the error is an HTTP 404 returned by the Outlyer API
+func IsNotFound(err error) bool {
+	httpErr, ok := err.(*HTTPError)
+	return ok && httpErr.Code == 404
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+// IsAuthError checks whether the error is returned by the Outlyer API because the API token
+// is invalid or does not have permissions to perform the operation, or because the API token
+// could not be obtained from the credential process or the encrypted token store
+func IsAuthError(err error) bool {
+	if _, ok := err.(*credentials.Error); ok {
+		return true
 	}
-	defer resp.Body.Close()
+	httpErr, ok := err.(*HTTPError)
+	return ok && (httpErr.Code == 401 || httpErr.Code == 403)
+}
 
-	content, err := ioutil.ReadAll(resp.Body)
+// Get will set the API token and default headers before issuing a GET request to Outlyer API
+func Get(endpoint string) ([]byte, error) {
+	code, content, err := do("GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("%s", content)
+	if code >= 400 {
+		return nil, &HTTPError{Code: code, Body: content}
 	}
 
 	return content, nil
@@ -64,38 +75,87 @@ func Patch(endpoint string, payload []byte) (*Response, error) {
 	return send(endpoint, "PATCH", payload)
 }
 
+// Delete will set the API token and default headers before issuing a DELETE request to Outlyer API
+func Delete(endpoint string) (*Response, error) {
+	return send(endpoint, "DELETE", nil)
+}
+
 // send wil issue an HTTP request for the given Outlyer API endpoint with the method and payload provided
 func send(endpoint, method string, payload []byte) (*Response, error) {
+	code, content, err := do(method, endpoint, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var errorDetail error
+	if detail := getHTTPErrorBy(code); detail != nil {
+		errorDetail = &HTTPError{Code: code, Body: content, Message: detail.Error()}
+	}
+	return &Response{Code: code, Body: content, ErrorDetail: errorDetail}, nil
+}
+
+// do issues an HTTP request to the Outlyer API, retrying it with exponential backoff
+// when it fails with an error that may be transient
+func do(method, endpoint string, payload []byte) (int, []byte, error) {
+	// Obtaining the token may run a credential process, which is not retried like requests
+	token, err := credentials.Token()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	retries := config.CLI.GetInt("retries")
+	for attempt := 0; ; attempt++ {
+		code, content, header, err := doOnce(method, endpoint, token, payload)
+		if attempt >= retries || !shouldRetry(method, code, err) {
+			return code, content, err
+		}
+
+		wait := getRetryWait(attempt, header)
+		atomic.AddInt64(&retryCount, 1)
+		reason := http.StatusText(code)
+		if err != nil {
+			reason = err.Error()
+		}
+		logVerbose("Retrying %s %s in %s after %s (retry %d of %d)", method, endpoint, wait.Round(time.Millisecond), reason, attempt+1, retries)
+		time.Sleep(wait)
+	}
+}
+
+// doOnce issues a single HTTP request to the Outlyer API
+func doOnce(method, endpoint, token string, payload []byte) (int, []byte, http.Header, error) {
 	baseURL := config.CLI.GetString("api-url")
 	completeURL := baseURL + endpoint
 
-	client := http.Client{}
-	req, err := http.NewRequest(method, completeURL, bytes.NewReader(payload))
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, completeURL, body)
 	if err != nil {
-		return nil, err
+		return 0, nil, nil, err
 	}
 
 	// Add request headers
-	token := config.CLI.GetString("api-token")
 	req.Header.Add(http.CanonicalHeaderKey("Authorization"), fmt.Sprintf("Bearer %s", token))
 	req.Header.Add(http.CanonicalHeaderKey("Content-Type"), config.CLI.GetString("headers.post.content-type"))
-	commonHeaders := config.CLI.GetStringMapString("headers.common")
+	commonHeaders := config.CommonHeaders()
 	for k, v := range commonHeaders {
 		req.Header.Add(http.CanonicalHeaderKey(k), v)
 	}
 
-	resp, err := client.Do(req)
+	resp, err := getClient().Do(req)
 	if err != nil {
-		return nil, err
+		return 0, nil, nil, err
 	}
 	defer resp.Body.Close()
 
 	content, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return 0, nil, nil, err
 	}
 
-	return &Response{Code: resp.StatusCode, Body: content, ErrorDetail: getHTTPErrorBy(resp.StatusCode)}, nil
+	logVerbose("%s %s: %s", method, endpoint, resp.Status)
+	return resp.StatusCode, content, resp.Header, nil
 }
 
 func getHTTPErrorBy(responseCode int) error {
@@ -106,12 +166,15 @@ func getHTTPErrorBy(responseCode int) error {
 	if responseCode == 400 {
 		err = fmt.Errorf("incorrect resource definition, ensure all fields are correct and try again")
 	}
-	if responseCode == 401 {
+	if responseCode == 401 || responseCode == 403 {
 		err = fmt.Errorf("you don't have permissions to perform this operation")
 	}
 	if responseCode == 404 {
 		err = fmt.Errorf("resource not found")
 	}
+	if responseCode == 429 {
+		err = fmt.Errorf("too many requests to the Outlyer API, try again later or reduce the parallelism")
+	}
 	if responseCode >= 500 && responseCode < 600 {
 		err = fmt.Errorf("Outlyer API is unavailable, try again later")
 	}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/outlyerapp/outlyer-cli/config"
+)
+
+// retryCount counts all requests retried since the CLI started
+var retryCount int64
+
+var (
+	transport     *http.Transport
+	transportOnce sync.Once
+)
+
+// RetryCount returns how many requests were retried since the CLI started
+func RetryCount() int {
+	return int(atomic.LoadInt64(&retryCount))
+}
+
+// getClient returns an HTTP client with the timeouts set in the configuration.
+// All clients share the same transport so connections are reused between requests.
+func getClient() *http.Client {
+	transportOnce.Do(func() {
+		connectTimeout := config.CLI.GetDuration("connect-timeout")
+		transport = &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}).DialContext,
+			TLSHandshakeTimeout:   connectTimeout,
+			ResponseHeaderTimeout: config.CLI.GetDuration("read-timeout"),
+			MaxIdleConnsPerHost:   config.CLI.GetInt("parallelism"),
+		}
+	})
+	return &http.Client{Transport: transport, Timeout: config.CLI.GetDuration("timeout")}
+}
+
+// shouldRetry checks whether a failed request may succeed if issued again. Requests rejected because
+// of rate limiting are always retried since the API did not process them, while network errors and
+// server errors are only retried for idempotent requests.
+func shouldRetry(method string, code int, err error) bool {
+	if err == nil && code == http.StatusTooManyRequests {
+		return true
+	}
+	if !isIdempotent(method) {
+		return false
+	}
+	return err != nil || code >= 500
+}
+
+// isIdempotent checks whether issuing the request several times has the same effect as issuing it once.
+// PATCH is considered idempotent since the CLI always sends the whole resource definition.
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "PATCH", "DELETE":
+		return true
+	}
+	return false
+}
+
+// getRetryWait returns how long to wait before the next retry. It honors the Retry-After header
+// if present, otherwise it uses exponential backoff with jitter. Either way it waits at most the
+// configured max-retry-wait, so a server asking to retry much later can't hang the command.
+func getRetryWait(attempt int, header http.Header) time.Duration {
+	maxWait := config.CLI.GetDuration("max-retry-wait")
+	if retryAfter := parseRetryAfter(header.Get("Retry-After")); retryAfter > 0 {
+		if retryAfter > maxWait {
+			return maxWait
+		}
+		return retryAfter
+	}
+
+	wait := config.CLI.GetDuration("retry-wait") << uint(attempt)
+	if wait <= 0 || wait > maxWait {
+		wait = maxWait
+	}
+	// Waits between half and the whole backoff so concurrent requests don't retry at the same time
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// parseRetryAfter parses the Retry-After header, which contains either seconds or an HTTP date
+func parseRetryAfter(retryAfter string) time.Duration {
+	if retryAfter == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil && time.Until(date) > 0 {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// logVerbose prints a message to stderr when verbose output is enabled
+func logVerbose(format string, args ...interface{}) {
+	if config.CLI.GetBool("verbose") {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+}
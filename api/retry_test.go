@@ -0,0 +1,80 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestShouldRetry(t *testing.T) {
+	tests := []struct {
+		method string
+		code   int
+		err    error
+		want   bool
+	}{
+		{"GET", 200, nil, false},
+		{"GET", 404, nil, false},
+		{"GET", 502, nil, true},
+		{"GET", 0, fmt.Errorf("connection reset"), true},
+		{"PATCH", 503, nil, true},
+		{"DELETE", 429, nil, true},
+		{"POST", 429, nil, true},
+		{"POST", 502, nil, false},
+		{"POST", 0, fmt.Errorf("connection reset"), false},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %d %v", test.method, test.code, test.err), func(t *testing.T) {
+			if got := shouldRetry(test.method, test.code, test.err); got != test.want {
+				t.Errorf("shouldRetry() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		retryAfter string
+		want       time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"invalid", 0},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0},
+	}
+	for _, test := range tests {
+		t.Run(test.retryAfter, func(t *testing.T) {
+			if got := parseRetryAfter(test.retryAfter); got != test.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetRetryWait(t *testing.T) {
+	// The default retry-wait and max-retry-wait are 1s and 30s
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		min, max   time.Duration
+	}{
+		{"Retry-After", 0, "5", 5 * time.Second, 5 * time.Second},
+		{"Retry-After over the max wait", 0, "3600", 30 * time.Second, 30 * time.Second},
+		{"Retry-After date over the max wait", 0, time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 30 * time.Second, 30 * time.Second},
+		{"backoff", 2, "", 2 * time.Second, 4 * time.Second},
+		{"backoff over the max wait", 10, "", 15 * time.Second, 30 * time.Second},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := http.Header{}
+			if test.retryAfter != "" {
+				header.Set("Retry-After", test.retryAfter)
+			}
+			if got := getRetryWait(test.attempt, header); got < test.min || got > test.max {
+				t.Errorf("getRetryWait() = %v, want between %v and %v", got, test.min, test.max)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/outlyerapp/outlyer-cli/command"
+	"github.com/outlyerapp/outlyer-cli/config"
 	"github.com/spf13/cobra"
 )
 
@@ -11,12 +12,33 @@ func init() {
 	rootCmd = &cobra.Command{
 		Use:   "outlyer",
 		Short: "Outlyer CLI allows to easily manage your Outlyer account via command line",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			command.LoadConfig(cmd)
+		},
 	}
+	rootCmd.PersistentFlags().Duration("timeout", config.CLI.GetDuration("timeout"), "Maximum time to wait for each request to the Outlyer API, like '30s' or '2m'")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Prints every request to the Outlyer API and its retries")
+	rootCmd.PersistentFlags().String("config", "", "Configuration file to use instead of ~/.outlyer.yaml")
+	rootCmd.PersistentFlags().String("profile", "", "Profile of the configuration file to use. Can also be set with the "+config.ProfileEnv+" environment variable")
+	rootCmd.PersistentFlags().StringP("output", "o", config.CLI.GetString("output"), "Output format: table, json, yaml, go-template=<template> or jsonpath=<expression>")
+	config.CLI.BindPFlag("timeout", rootCmd.PersistentFlags().Lookup("timeout"))
+	config.CLI.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+	config.CLI.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output"))
+	config.CLI.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
+
 	rootCmd.AddCommand(
 		command.NewConfigureCommand(),
+		command.NewConfigCommand(),
 		command.NewGetCommand(),
+		command.NewDescribeCommand(),
 		command.NewExportCommand(),
-		command.NewApplyCommand())
+		command.NewApplyCommand(),
+		command.NewCopyCommand(),
+		command.NewDiffCommand(),
+		command.NewValidateCommand(),
+		command.NewLintCommand(),
+		command.NewPlanCommand(),
+		command.NewDeleteCommand())
 }
 
 func main() {
@@ -1,21 +1,25 @@
 package command
 
 import (
-	"bufio"
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
-	"sync"
 
 	"gopkg.in/yaml.v2"
 
 	"github.com/outlyerapp/outlyer-cli/api"
+	"github.com/outlyerapp/outlyer-cli/config"
 	"github.com/spf13/cobra"
 )
 
+// statusSkipped is the status of resources not applied because a resource they depend on failed
+const statusSkipped = "SKIPPED"
+
 type plugin struct {
 	Content  string `yaml:"content"`
 	Encoding string `yaml:"encoding"`
@@ -23,28 +27,47 @@ type plugin struct {
 }
 
 type resource struct {
-	path   string
-	bytes  []byte
-	status string
-	err    error
+	path    string
+	name    string
+	bytes   []byte
+	status  string
+	err     error
+	diff    string
+	action  string
+	changes []fieldChange
+	remote  []byte
+
+	fingerprint string
 }
 
+// The resource type is the last folder of the path named like a type, so folders like 'checks-staging/'
+// or '/srv/alerts-config/' above the resource folders are not mistaken for the type. It's empty if there's
+// no such folder, though getPaths only returns files directly in a resource folder.
 func (r *resource) getType() string {
-	regex := regexp.MustCompile(`(alerts|checks|dashboards|plugins|views)`)
+	regex := regexp.MustCompile(`^(?:.*/)?(alerts|checks|dashboards|plugins|views)/`)
 	res := regex.FindStringSubmatch(r.path)
-	return res[0]
+	if res == nil {
+		return ""
+	}
+	return res[1]
 }
 
 func (r *resource) getTypeAndName() string {
-	regex := regexp.MustCompile(`(alerts|checks|dashboards|plugins|views)/[^.]+`)
+	regex := regexp.MustCompile(`^(?:.*/)?((alerts|checks|dashboards|plugins|views)/[^.]+)`)
 	res := regex.FindStringSubmatch(r.path)
-	return res[0]
+	if res == nil {
+		return ""
+	}
+	return res[1]
 }
 
 func (r *resource) getTypeAndNameWithExtension() string {
-	regex := regexp.MustCompile(`(alerts|checks|dashboards|plugins|views)/.+`)
+	regex := regexp.MustCompile(`^(?:.*/)?((alerts|checks|dashboards|plugins|views)/.+)`)
 	res := regex.FindStringSubmatch(r.path)
-	return res[0]
+	if res == nil {
+		return ""
+	}
+	return res[1]
 }
 
 func (r *resource) getNameWithExtension() string {
@@ -53,10 +76,28 @@ func (r *resource) getNameWithExtension() string {
 	return res[2]
 }
 
+// getName returns the name identifying the resource in the Outlyer API. It's the name in the definition
+// of the resource, or otherwise the name mapped to the file name, like 'CPU Load' for 'CPU%20Load.yaml'.
+func (r *resource) getName() string {
+	if r.name != "" {
+		return r.name
+	}
+	fileName := r.getNameWithExtension()
+	if r.getType() != Plugins {
+		fileName = strings.TrimSuffix(fileName, path.Ext(fileName))
+	}
+	return fileNameToName(fileName)
+}
+
+// getAPIPath returns the resource path used by the Outlyer API, like 'alerts/docker' or 'plugins/docker.py'
+func (r *resource) getAPIPath() string {
+	return r.getType() + "/" + r.getName()
+}
+
 // NewApplyCommand creates a Command for applying resources to the user's Outlyer account
 func NewApplyCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "apply .|[folder]|[file]",
+		Use:   "apply .|[folder]|[file]|[plan file]",
 		Short: "Updates a resource (or a set of resources) if it already exists or creates it otherwise. The available resources are: alerts, checks, dashboards, plugins and views",
 		Example: `
 Suppose the following directory structure:
@@ -89,16 +130,50 @@ Applies all dashboards and the elasticsearch plugin to the account by executing
 $ outlyer apply dashboards plugins/elasticsearch.py --account=<your_account>
 
 Applies all dashboards and the elasticsearch plugin to the account by executing the command from outside the 'demo' directory:
-$ outlyer apply path_to/demo/dashboards path_to/demo/plugins/elasticsearch.yaml --account=<your_account>`,
+$ outlyer apply path_to/demo/dashboards path_to/demo/plugins/elasticsearch.yaml --account=<your_account>
+
+Shows which resources would be created or updated, and which fields would change, without applying them:
+$ outlyer apply . --account=<your_account> --dry-run
+
+Applies all alerts and deletes the alerts from the account that no longer exist in the 'alerts' directory:
+$ outlyer apply path_to/demo/alerts --account=<your_account> --prune
+
+Applies exactly the changes saved by 'outlyer plan', refusing if any resource changed in the account since then:
+$ outlyer apply plan.out
+
+Applies all resources rendering their templated values, like {{ .Values.threshold }}, with the production values
+and a threshold overridden:
+$ outlyer apply . --account=<your_account> --values=values/prod.yaml --set threshold=90
+
+Applies the effective resources of an overlay, a folder whose overlay.yaml file patches, adds or removes
+resources of its base folders:
+$ outlyer apply path_to/overlays/prod --account=<your_account>
+
+Applies all resources without asking for confirmation, like in CI pipelines where stdin is not a terminal:
+$ outlyer apply . --account=<your_account> --yes`,
 		Run: applyCommand,
 	}
 
-	cmd.PersistentFlags().StringP("account", "a", "", "(Required) User account to use")
+	cmd.PersistentFlags().StringP("account", "a", "", "User account to use. Required unless a default account is set with the 'default-account' configuration or the "+config.AccountEnv+" environment variable")
+	cmd.PersistentFlags().Bool("dry-run", false, "(Optional) Shows what would be created, updated or deleted without applying any changes")
+	cmd.PersistentFlags().Bool("prune", false, "(Optional) Deletes resources from the account that no longer exist in the included resource folders")
+	cmd.PersistentFlags().Int("parallelism", 10, "(Optional) Maximum number of concurrent requests to the Outlyer API. Can also be set with the 'parallelism' configuration")
+	cmd.PersistentFlags().StringArray("values", nil, "(Optional) YAML file with the values to render templated resources with, like {{ .Values.threshold }}. Resources are only rendered when values are given. Can be repeated, later files override earlier ones")
+	cmd.PersistentFlags().StringArray("set", nil, "(Optional) Sets a value to render templated resources with, like --set threshold=90. Can be repeated and overrides the values files")
+	cmd.PersistentFlags().BoolP("yes", "y", false, "(Optional) Applies without asking for confirmation. Can also be set with the "+assumeYesEnv+" environment variable")
 	return cmd
 }
 
 func applyCommand(cmd *cobra.Command, args []string) {
-	account := cmd.PersistentFlags().Lookup("account").Value.String()
+	printer := newPrinter()
+	if len(args) == 1 {
+		if plan, ok := readPlan(args[0]); ok {
+			applySavedPlan(cmd, printer, args[0], plan)
+			return
+		}
+	}
+
+	account := getAccount(cmd)
 	if account == "" {
 		ExitWithError(ExitBadArgs, fmt.Errorf("Account is required"))
 	}
@@ -107,84 +182,192 @@ func applyCommand(cmd *cobra.Command, args []string) {
 		ExitWithError(ExitBadArgs, fmt.Errorf("Resource is required"))
 	}
 
-	paths := getPaths(args)
-	resources := getResources(paths)
+	prune, _ := cmd.PersistentFlags().GetBool("prune")
+	resources := loadResources(args, getValues(cmd), prune)
+	requireValidResources(resources)
+	if prune {
+		resources = append(resources, getPrunedResources(args, resources, listRemoteNames(account))...)
+	}
+	pool := newWorkerPool(cmd)
+	planResources(pool, account, resources)
+
+	dryRun, _ := cmd.PersistentFlags().GetBool("dry-run")
+	if dryRun {
+		showPlan(printer, account, resources)
+		fmt.Fprintln(printer.messages(), "\nDry run. 0 resources applied.")
+		return
+	}
+	printPlan(os.Stderr, account, resources)
+	confirmAndApply(cmd, printer, pool, account, resources)
+}
 
-	fmt.Printf("\nResources to apply...\n\n")
-	for _, resource := range resources {
-		fmt.Printf("\t- %s\n", resource.path)
+// applySavedPlan applies exactly the changes recorded in a plan file, refusing to do so
+// if any resource changed in the account since the plan was created
+func applySavedPlan(cmd *cobra.Command, printer *printer, path string, plan *savedPlan) {
+	// The plan is applied to its own account, unless another one is given or configured by default
+	if account := getAccount(cmd); account != "" && account != plan.Account {
+		ExitWithError(ExitBadArgs, fmt.Errorf("plan %s was created for account '%s', not '%s'", path, plan.Account, account))
 	}
-	fmt.Printf("\nAre you sure you want to apply to account '%s'? [y/n] ", account)
 
-	reader := bufio.NewReader(os.Stdin)
-	confirmation, _ := reader.ReadString('\n')
-	confirmation = strings.Replace(confirmation, "\n", "", -1) // removes return character on *unix and darwin
-	confirmation = strings.Replace(confirmation, "\r", "", -1) // removes return character on windows
+	pool := newWorkerPool(cmd)
+	resources := getPlannedResources(plan)
+	if outdated := verifyPlan(pool, plan, resources); len(outdated) > 0 {
+		ExitWithError(ExitError, fmt.Errorf("plan %s is outdated, create a new plan and try again\n\t- %s", path, strings.Join(outdated, "\n\t- ")))
+	}
 
-	if confirmation == "y" || confirmation == "Y" {
-		// Creates WaitGroup to wait for goroutines to finish applying resources concurrently
-		var wg sync.WaitGroup
+	dryRun, _ := cmd.PersistentFlags().GetBool("dry-run")
+	if dryRun {
+		showPlan(printer, plan.Account, resources)
+		fmt.Fprintln(printer.messages(), "\nDry run. 0 resources applied.")
+		return
+	}
+	printPlan(os.Stderr, plan.Account, resources)
+	confirmAndApply(cmd, printer, pool, plan.Account, resources)
+}
 
-		for i := 0; i < len(resources); i++ {
-			wg.Add(1)
-			go apply(account, &resources[i], &wg)
-		}
+// confirmAndApply asks the user to confirm the plan and applies all classified resources
+func confirmAndApply(cmd *cobra.Command, printer *printer, pool *workerPool, account string, resources []resource) {
+	if !hasChanges(resources) {
+		exitWithResults(resources)
+		fmt.Fprintln(printer.messages(), "\nNo changes. 0 resources applied.")
+		return
+	}
+	if askForConfirmation(cmd, fmt.Sprintf("Are you sure you want to apply to account '%s'?", account)) {
+		applyResources(pool, account, resources)
+		printResults(printer, account, resources)
+		exitWithResults(resources)
+	} else {
+		fmt.Fprintln(printer.messages(), "Skipping apply. 0 resources applied.")
+	}
+}
 
-		wg.Wait()
-		fmt.Println("")
-		fmt.Printf(getColumnPattern(), "ACCOUNT", "RESOURCE", "STATUS", "REASON")
-		for _, resource := range resources {
-			if resource.err == nil {
-				fmt.Printf(getColumnPattern(), account, resource.getTypeAndNameWithExtension(), resource.status, "")
-			} else {
-				fmt.Printf(getColumnPattern(), account, resource.getTypeAndNameWithExtension(), "FAIL", resource.err)
+// applyResources applies all classified resources to the account in dependency order. Resources within
+// the same wave are applied concurrently, and resources referencing a resource that could not be applied are skipped.
+func applyResources(pool *workerPool, account string, resources []resource) {
+	waves, dependencies := getApplyWaves(resources)
+	for _, wave := range waves {
+		var toApply []int
+		for _, i := range wave {
+			if failed := getFailedDependency(resources, dependencies[i]); failed != nil {
+				resources[i].status = statusSkipped
+				resources[i].err = fmt.Errorf("depends on %s which could not be applied", failed.getTypeAndNameWithExtension())
+				continue
 			}
+			toApply = append(toApply, i)
 		}
-		fmt.Println("")
-	} else {
-		fmt.Println("Skipping apply. 0 resources applied.")
+
+		pool.run(len(toApply), func(j int) string {
+			resource := &resources[toApply[j]]
+			apply(account, resource)
+			return "applied " + resource.getTypeAndNameWithExtension()
+		})
 	}
 }
 
-func apply(account string, resource *resource, wg *sync.WaitGroup) {
-	var resp *api.Response
-	var err error
-	if resource.getType() == Plugins {
-		resp, err = api.Patch("/accounts/"+account+"/"+resource.getTypeAndNameWithExtension(), resource.bytes)
-	} else {
-		resp, err = api.Patch("/accounts/"+account+"/"+resource.getTypeAndName(), resource.bytes)
+// getFailedDependency returns the first dependency that failed or was skipped, if any
+func getFailedDependency(resources []resource, dependencies []int) *resource {
+	for _, i := range dependencies {
+		if resources[i].err != nil {
+			return &resources[i]
+		}
 	}
-	if err != nil {
-		ExitWithError(ExitError, fmt.Errorf("could not process request"))
+	return nil
+}
+
+// resultColumns are the columns of the table printed with the status of every resource
+var resultColumns = []column{
+	{header: "ACCOUNT", fields: []string{"account"}},
+	{header: "RESOURCE", fields: []string{"resource"}},
+	{header: "STATUS", fields: []string{"status"}},
+	{header: "REASON", fields: []string{"reason"}},
+}
+
+// printResults prints the status of every resource in the selected output format
+func printResults(printer *printer, account string, resources []resource) {
+	items := make([]map[string]interface{}, len(resources))
+	for i, resource := range resources {
+		items[i] = getResultItem(account, resource)
 	}
 
-	resource.status = "OK [UPDATED]"
-	resource.err = resp.ErrorDetail
+	if printer.isTable() {
+		fmt.Println("")
+	}
+	if err := printer.print(resultColumns, items); err != nil {
+		ExitWithError(ExitError, fmt.Errorf("Could not print results\n%s", err))
+	}
+	if printer.isTable() {
+		fmt.Println("")
+	}
+	printRetries()
+}
 
-	if resp.Code == 404 {
-		resp, err = api.Post("/accounts/"+account+"/"+resource.getType(), resource.bytes)
-		if err != nil {
-			ExitWithError(ExitError, fmt.Errorf("could not process request"))
+// getResultItem returns the status of the resource as printed by printResults
+func getResultItem(account string, resource resource) map[string]interface{} {
+	item := map[string]interface{}{
+		"account":  account,
+		"resource": resource.getTypeAndNameWithExtension(),
+		"status":   resource.status,
+		"reason":   "",
+	}
+	if resource.err != nil {
+		if resource.status != statusSkipped {
+			item["status"] = "FAIL"
 		}
+		item["reason"] = resource.err.Error()
+	}
+	return item
+}
+
+// printRetries prints how many requests to the Outlyer API had to be retried, if any
+func printRetries() {
+	if retries := api.RetryCount(); retries > 0 {
+		fmt.Fprintf(os.Stderr, "%d requests to the Outlyer API were retried, use --verbose for details\n", retries)
+	}
+}
+
+// apply creates, updates or deletes the resource according to the action it was classified with
+func apply(account string, resource *resource) {
+	if resource.err != nil {
+		return
+	}
+
+	var resp *api.Response
+	var err error
+	switch resource.action {
+	case actionCreate:
+		resp, err = api.Post(accountPath(account, resource.getType()), resource.bytes)
 		resource.status = "OK [CREATED]"
-		resource.err = resp.ErrorDetail
+	case actionUpdate:
+		resp, err = api.Patch(accountPath(account, resource.getAPIPath()), resource.bytes)
+		resource.status = "OK [UPDATED]"
+	case actionDelete:
+		resp, err = api.Delete(accountPath(account, resource.getAPIPath()))
+		resource.status = "OK [DELETED]"
+	default:
+		resource.status = "OK [UNCHANGED]"
+		return
 	}
-	wg.Done()
+	if err != nil {
+		resource.err = fmt.Errorf("could not process request: %s", err)
+		return
+	}
+	resource.err = resp.ErrorDetail
 }
 
-func getPaths(args []string) []string {
+// getPaths returns the resource files of the given files and folders. It exits if there are none, unless
+// allowEmpty is set, like when pruning, where an empty resource folder means deleting all resources of its type.
+func getPaths(args []string, allowEmpty bool) []string {
 	var paths []string
 
 	for _, arg := range args {
 		if !fileOrDirExists(arg) {
-			ExitWithError(ExitError, fmt.Errorf("%s: no such file or directory", arg))
+			ExitWithError(ExitBadArgs, fmt.Errorf("%s: no such file or directory", arg))
 		}
 
 		fileInfo, _ := os.Stat(arg)
 		if fileInfo.IsDir() {
 			arg = appendSlashTo(arg)
-			dirWithResourceName, _ := regexp.Compile("(alerts|checks|dashboards|plugins|views)/")
-			if dirWithResourceName.MatchString(arg) { // Is the dir a resource name?
+			if isResourceType(filepath.Base(arg)) { // Is the dir a resource name?
 				files, _ := ioutil.ReadDir(arg)
 				for _, file := range files { // Then add all resources from it
 					paths = append(paths, arg+file.Name())
@@ -194,60 +377,98 @@ func getPaths(args []string) []string {
 				// Covers the case "apply dir1/ --account=my-account", where dir1 has subdirs "alerts", "checks", etc
 				files, _ := ioutil.ReadDir(arg)
 				for _, file := range files {
-					if file.IsDir() {
-						regex, _ := regexp.Compile("(alerts|checks|dashboards|plugins|views)")
-						if regex.MatchString(file.Name()) {
-							resources, _ := ioutil.ReadDir(arg + file.Name())
-							for _, resource := range resources {
-								paths = append(paths, arg+file.Name()+"/"+resource.Name())
-							}
+					if file.IsDir() && isResourceType(file.Name()) {
+						resources, _ := ioutil.ReadDir(arg + file.Name())
+						for _, resource := range resources {
+							paths = append(paths, arg+file.Name()+"/"+resource.Name())
 						}
 					}
 				}
 			}
-		} else {
-			validResourcePath, _ := regexp.Compile("(.*)(alerts|checks|dashboards|plugins|views)/[^.]+...[^.]")
-			if validResourcePath.MatchString(arg) {
-				paths = append(paths, arg)
-			}
+		} else if isResourcePath(arg) {
+			paths = append(paths, arg)
 		}
 	}
 
 	paths = removeDuplicates(paths)
 
-	if len(paths) == 0 {
+	if len(paths) == 0 && !allowEmpty {
 		ExitWithError(ExitError, fmt.Errorf("could not find any resources to apply"))
 	}
 
 	return paths
 }
 
-func getResources(paths []string) []resource {
+// isResourcePath tells whether the file is a resource, that is a file with an extension right in a folder
+// named after a resource type, like 'demo/checks/redis.yaml' but not 'demo/checks-staging/redis.yaml'
+func isResourcePath(path string) bool {
+	fileName := regexp.MustCompile("^[^.]+...[^.]")
+	return isResourceType(filepath.Base(filepath.Dir(path))) && fileName.MatchString(filepath.Base(path))
+}
+
+// getIncludedTypes returns the resource types whose whole folder is included by the given arguments,
+// like 'alerts' for both 'demo/alerts' and 'demo'. Single resource files do not include their type.
+func getIncludedTypes(args []string) []string {
+	var types []string
+
+	for _, arg := range args {
+		fileInfo, err := os.Stat(arg)
+		if err != nil || !fileInfo.IsDir() {
+			continue
+		}
+		if isOverlay(arg) {
+			types = append(types, getOverlayTypes(arg)...)
+			continue
+		}
+
+		if resourceType := filepath.Base(arg); isResourceType(resourceType) {
+			types = append(types, resourceType)
+		} else {
+			files, _ := ioutil.ReadDir(arg)
+			for _, file := range files {
+				if file.IsDir() && isResourceType(file.Name()) {
+					types = append(types, file.Name())
+				}
+			}
+		}
+	}
+	return removeDuplicates(types)
+}
+
+// getResources reads the resource files, rendering the templated values of all but plugins when values are
+// given. It exits listing every file that could not be rendered before any resource is compared or applied.
+func getResources(paths []string, values map[interface{}]interface{}) []resource {
 	resources := make([]resource, len(paths))
+	var renderErrors []string
 	for i, path := range paths {
 		bytes, err := ioutil.ReadFile(path)
 		if err != nil {
 			ExitWithError(ExitError, err)
 		}
 
-		res := resource{path, bytes, "FAIL", nil}
+		res := resource{path: path, bytes: bytes, status: "FAIL"}
 		if res.getType() == Plugins {
 			res.bytes = bytes
 			res = convertPlugin(res)
+		} else if values == nil {
+			res.bytes = bytes
+		} else if res.bytes, err = renderResource(path, bytes, values); err != nil {
+			renderErrors = append(renderErrors, err.Error())
 		}
+		res.name = readResourceName(res)
 		resources[i] = res
 	}
+
+	if len(renderErrors) > 0 {
+		ExitWithError(ExitError, fmt.Errorf("could not render resource templates\n\t- %s", strings.Join(renderErrors, "\n\t- ")))
+	}
 	return resources
 }
 
 func convertPlugin(res resource) resource {
 	pluginBase64 := base64.StdEncoding.EncodeToString(res.bytes)
-	plugin := &plugin{Content: pluginBase64, Name: res.getNameWithExtension(), Encoding: "base64"}
+	plugin := &plugin{Content: pluginBase64, Name: fileNameToName(res.getNameWithExtension()), Encoding: "base64"}
 	pluginInBytes, _ := yaml.Marshal(&plugin)
 	res.bytes = pluginInBytes
 	return res
 }
-
-func getColumnPattern() string {
-	return "%-20s\t%-40s\t%-20s\t%-25v\n"
-}
@@ -1,6 +1,10 @@
 package command
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -21,6 +25,11 @@ func TestGetType(t *testing.T) {
 		{"plugins/docker.py", "plugins"},
 		{"dir1/plugins/docker.py", "plugins"},
 		{"/dir1/dir2/plugins/docker.py", "plugins"},
+		// Folders named after an account or a project may contain the name of another type
+		{"checks-staging/dashboards/docker.yaml", "dashboards"},
+		{"/srv/alerts-config/checks/docker.yaml", "checks"},
+		{"alerts/dashboards/docker.yaml", "dashboards"},
+		{"checks-staging/docker.yaml", ""},
 	}
 	for _, test := range tests {
 		t.Run(test.path, func(t *testing.T) {
@@ -51,6 +60,8 @@ func TestGetTypeAndName(t *testing.T) {
 		{"plugins/docker.py", "plugins/docker"},
 		{"dir1/plugins/docker.py", "plugins/docker"},
 		{"/dir1/dir2/plugins/docker.py", "plugins/docker"},
+		{"checks-staging/dashboards/docker.yaml", "dashboards/docker"},
+		{"checks-staging/docker.yaml", ""},
 	}
 	for _, test := range tests {
 		t.Run(test.path, func(t *testing.T) {
@@ -81,6 +92,8 @@ func TestGetTypeAndNameWithExtension(t *testing.T) {
 		{"plugins/docker.py", "plugins/docker.py"},
 		{"dir1/plugins/docker.py", "plugins/docker.py"},
 		{"/dir1/dir2/plugins/docker.py", "plugins/docker.py"},
+		{"checks-staging/dashboards/docker.yaml", "dashboards/docker.yaml"},
+		{"checks-staging/docker.yaml", ""},
 	}
 	for _, test := range tests {
 		t.Run(test.path, func(t *testing.T) {
@@ -94,6 +107,41 @@ func TestGetTypeAndNameWithExtension(t *testing.T) {
 	}
 }
 
+func TestGetPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paths")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// The checks of another account next to the resource folders must not be taken for checks
+	for _, folder := range []string{"checks", "checks-staging"} {
+		os.MkdirAll(filepath.Join(dir, folder), 0755)
+		if err := ioutil.WriteFile(filepath.Join(dir, folder, "redis.yaml"), []byte("name: redis\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	redis := filepath.Join(dir, "checks", "redis.yaml")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"folder", []string{dir}, []string{redis}},
+		{"type folder", []string{filepath.Join(dir, "checks")}, []string{redis}},
+		{"file", []string{redis}, []string{redis}},
+		{"file in other folder", []string{filepath.Join(dir, "checks-staging", "redis.yaml")}, []string{}},
+		{"other folder", []string{filepath.Join(dir, "checks-staging")}, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := getPaths(test.args, true); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("getPaths() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
 func TestGetNameWithExtension(t *testing.T) {
 	tests := []struct {
 		path string
@@ -123,3 +171,30 @@ func TestGetNameWithExtension(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAPIPath(t *testing.T) {
+	tests := []struct {
+		path string
+		name string
+		want string
+	}{
+		{"alerts/docker.yaml", "", "alerts/docker"},
+		{"dir1/alerts/docker.yaml", "Docker containers", "alerts/Docker containers"},
+		{"dashboards/CPU%20%2F%20Memory.yaml", "", "dashboards/CPU / Memory"},
+		{"checks/kafka.v2.yaml", "", "checks/kafka.v2"},
+		{"plugins/docker.py", "", "plugins/docker.py"},
+		{"plugins/my%20plugin.sh", "", "plugins/my plugin.sh"},
+		{"dashboards/CPU / Memory", "CPU / Memory", "dashboards/CPU / Memory"},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			r := &resource{
+				path: test.path,
+				name: test.name,
+			}
+			if got := r.getAPIPath(); got != test.want {
+				t.Errorf("resource.getAPIPath() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
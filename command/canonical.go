@@ -0,0 +1,120 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// volatileFields are the fields the Outlyer API manages itself, like ids, timestamps and who created
+// a resource. They change without the resource being edited, so they are left out of exported files
+// and ignored when comparing local and remote resources.
+var volatileFields = []string{"id", "uuid", "created_at", "created_by", "creator", "updated_at", "updated_by", "modified_at", "modified_by", "last_modified", "updated"}
+
+// canonicalKeyOrder lists the fields of each resource type in the order they are exported.
+// Fields not listed follow in alphabetical order, and the fields of nested objects are sorted
+// alphabetically after their name.
+var canonicalKeyOrder = map[string][]string{
+	Alerts:     {"name", "description", "enabled", "severity", "criteria", "notifications"},
+	Checks:     {"name", "description", "enabled", "command", "interval", "timeout", "selector", "env"},
+	Dashboards: {"name", "title", "description", "views", "widgets"},
+	Views:      {"name", "title", "description", "selector"},
+}
+
+// stripVolatileFields returns a copy of the resource definition without the fields managed by the Outlyer API
+func stripVolatileFields(definition map[interface{}]interface{}) map[interface{}]interface{} {
+	stripped := make(map[interface{}]interface{}, len(definition))
+	for key, value := range definition {
+		if !containsString(volatileFields, fmt.Sprint(key)) {
+			stripped[key] = value
+		}
+	}
+	return stripped
+}
+
+// normalizeStrings returns a copy of the value with its multi-line strings normalized to Unix line
+// endings and no trailing spaces, so they can always be written as YAML literal blocks
+func normalizeStrings(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		normalized := make(map[interface{}]interface{}, len(v))
+		for key, field := range v {
+			normalized[key] = normalizeStrings(field)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, item := range v {
+			normalized[i] = normalizeStrings(item)
+		}
+		return normalized
+	case string:
+		if !strings.ContainsAny(v, "\r\n") {
+			return v
+		}
+		lines := strings.Split(strings.Replace(v, "\r\n", "\n", -1), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(line, " \t\r")
+		}
+		return strings.Join(lines, "\n")
+	}
+	return value
+}
+
+// canonicalDefinition returns the resource definition as compared with other versions of itself:
+// without volatile fields and with its multi-line strings normalized
+func canonicalDefinition(definition map[interface{}]interface{}) map[interface{}]interface{} {
+	return normalizeStrings(stripVolatileFields(definition)).(map[interface{}]interface{})
+}
+
+// marshalCanonical writes the canonical definition of a resource as YAML, with its fields in the
+// canonical order of its type, so exporting an unchanged resource always writes the same file
+func marshalCanonical(resourceType string, definition map[interface{}]interface{}) ([]byte, error) {
+	return yaml.Marshal(orderFields(canonicalDefinition(definition), canonicalKeyOrder[resourceType]))
+}
+
+// orderFields converts the value to YAML with the fields of its objects in a stable order: the given
+// fields first for the top level object, the name first for nested objects, and then the rest sorted
+func orderFields(value interface{}, order []string) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		var keys []interface{}
+		for _, field := range order {
+			if _, found := v[field]; found {
+				keys = append(keys, field)
+			}
+		}
+		var rest []interface{}
+		for key := range v {
+			if !containsString(order, fmt.Sprint(key)) {
+				rest = append(rest, key)
+			}
+		}
+		sort.Slice(rest, func(i, j int) bool { return fmt.Sprint(rest[i]) < fmt.Sprint(rest[j]) })
+
+		ordered := make(yaml.MapSlice, 0, len(v))
+		for _, key := range append(keys, rest...) {
+			ordered = append(ordered, yaml.MapItem{Key: key, Value: orderFields(v[key], []string{"name"})})
+		}
+		return ordered
+	case []interface{}:
+		ordered := make([]interface{}, len(v))
+		for i, item := range v {
+			ordered[i] = orderFields(item, []string{"name"})
+		}
+		return ordered
+	}
+	return value
+}
+
+// decodeCanonical parses a resource definition to compare it with other versions of itself, so local
+// files exported without volatile fields match the remote resources. Plugins are decoded as they are.
+func decodeCanonical(resourceType string, content []byte) (map[interface{}]interface{}, error) {
+	definition, err := decodeResource(resourceType, content)
+	if err != nil || resourceType == Plugins {
+		return definition, err
+	}
+	return canonicalDefinition(definition), nil
+}
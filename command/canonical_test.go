@@ -0,0 +1,56 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestMarshalCanonical(t *testing.T) {
+	tests := []struct {
+		name         string
+		resourceType string
+		content      string
+		want         string
+	}{
+		{"orders fields", Alerts,
+			"severity: critical\nzone: eu\nname: docker\ncriteria:\n- threshold: 90\n  name: cpu\n  check: docker\nanother: true\n",
+			"name: docker\nseverity: critical\ncriteria:\n- name: cpu\n  check: docker\n  threshold: 90\nanother: true\nzone: eu\n"},
+		{"strips volatile fields", Checks,
+			"id: 12\nname: docker\ncreated_at: 2018-01-01\ncreated_by: jane\nupdated_at: 2018-05-01\ninterval: 30\nenv:\n  id: kept\n",
+			"name: docker\ninterval: 30\nenv:\n  id: kept\n"},
+		{"normalizes multi-line strings", Views,
+			"name: docker\ndescription: \"Docker hosts  \\r\\nin production \\n\"\n",
+			"name: docker\ndescription: |\n  Docker hosts\n  in production\n"},
+		{"unknown type", "others", "b: 2\na: 1\nname: x\n", "a: 1\nb: 2\nname: x\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			definition := make(map[interface{}]interface{})
+			if err := yaml.Unmarshal([]byte(test.content), &definition); err != nil {
+				t.Fatal(err)
+			}
+			got, err := marshalCanonical(test.resourceType, definition)
+			if err != nil {
+				t.Fatalf("marshalCanonical() error = %v", err)
+			}
+			if string(got) != test.want {
+				t.Errorf("marshalCanonical() =\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCanonical(t *testing.T) {
+	exported, _ := decodeCanonical(Checks, []byte("name: docker\ninterval: 30\n"))
+	remote, _ := decodeCanonical(Checks, []byte("interval: 30\nid: 12\nname: docker\nupdated_at: 2018-05-01\n"))
+	if !reflect.DeepEqual(exported, remote) {
+		t.Errorf("decodeCanonical() = %v, want %v", remote, exported)
+	}
+
+	plugin, _ := decodeCanonical(Plugins, []byte("name: docker.py\ncontent: cHJpbnQoMSkgIAo=\n"))
+	if want := map[interface{}]interface{}{"content": "print(1)  \n"}; !reflect.DeepEqual(plugin, want) {
+		t.Errorf("decodeCanonical() = %v, want %v", plugin, want)
+	}
+}
@@ -0,0 +1,197 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/outlyerapp/outlyer-cli/config"
+	"github.com/outlyerapp/outlyer-cli/credentials"
+	"github.com/spf13/cobra"
+)
+
+// writesConfig annotates the commands that write the configuration file, so they can be run
+// before the file given with --config or the profile selected with --profile exist
+const writesConfig = "writes-config"
+
+// profileColumns are the columns of the table listing the profiles
+var profileColumns = []column{
+	{header: "CURRENT", fields: []string{"current"}},
+	{header: "NAME", fields: []string{"name"}},
+	{header: "API URL", fields: []string{"api-url"}},
+	{header: "DEFAULT ACCOUNT", fields: []string{"default-account"}},
+}
+
+// NewConfigCommand groups subcommands to manage the Outlyer CLI configuration and its profiles
+func NewConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the Outlyer CLI configuration and its profiles",
+		Long: `Manage the Outlyer CLI configuration and its profiles.
+
+Profiles allow using different API tokens, API URLs, default accounts and headers, for example to work
+with several organisations or with a staging API. The settings of the selected profile override the
+top-level settings of the configuration file. The profile is selected with the --profile flag, the
+` + config.ProfileEnv + ` environment variable or 'outlyer config use-profile', in that order.
+
+Settings are taken from, in order of precedence: command line flags, environment variables
+(` + config.AccountEnv + `, ` + config.APITokenEnv + ` and ` + config.APIURLEnv + `), the selected profile,
+the top-level settings of the configuration file and the defaults.
+
+The API token may be obtained from a command instead of being stored in plaintext, with
+'outlyer config set credential-process "<command>"'. The command prints the token to stdout, either
+as plain text or as JSON like {"token": "...", "expiration": "<RFC 3339 time>"}, and it's run again
+when the token expires. Alternatively, 'outlyer configure --encrypt' stores the token encrypted with a
+passphrase, which is asked when the token is used unless it's set with ` + credentials.PassphraseEnv + `.`,
+	}
+	cmd.AddCommand(newGetProfilesCommand(), newUseProfileCommand(), newConfigSetCommand())
+	return cmd
+}
+
+func newGetProfilesCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get-profiles",
+		Short: "List the profiles in the configuration file",
+		Run:   getProfilesCommand,
+	}
+}
+
+func newUseProfileCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use-profile [name]",
+		Short: "Select the profile used when no --profile flag or " + config.ProfileEnv + " environment variable is set",
+		Run:   useProfileCommand,
+	}
+}
+
+func newConfigSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set [key] [value]",
+		Short: "Set a configuration key in the selected profile, or at the top level if no profile is selected",
+		Example: `
+Creates the staging profile with its own API URL and default account:
+$ outlyer config set api-url https://api.staging.example.com/v2 --profile=staging
+$ outlyer config set default-account <your_account> --profile=staging
+
+Sends an additional header with every request made with the staging profile:
+$ outlyer config set headers.common.x-team ops --profile=staging`,
+		Annotations: map[string]string{writesConfig: "true"},
+		Run:         configSetCommand,
+	}
+}
+
+// LoadConfig reads the configuration file given with --config, or the one in the user's home directory,
+// and applies the selected profile. It exits if any of them doesn't exist unless the command creates them.
+func LoadConfig(cmd *cobra.Command) {
+	path, _ := cmd.Flags().GetString("config")
+	writes := cmd.Annotations[writesConfig] == "true"
+	err := config.Load(path, !writes)
+	if _, notFound := err.(*config.ProfileNotFoundError); notFound && writes {
+		return
+	}
+	if err != nil {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Could not load the configuration\n%s", err))
+	}
+}
+
+// getProfilesCommand lists the profiles in the configuration file, marking the selected one
+func getProfilesCommand(cmd *cobra.Command, args []string) {
+	printer := newPrinter()
+	profiles := readProfiles()
+
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, fmt.Sprint(name))
+	}
+	sort.Strings(names)
+
+	current := strings.ToLower(config.ProfileName())
+	items := make([]map[string]interface{}, len(names))
+	for i, name := range names {
+		items[i] = map[string]interface{}{"name": name, "current": ""}
+		if strings.ToLower(name) == current {
+			items[i]["current"] = "*"
+		}
+		if profile, ok := profiles[name].(map[interface{}]interface{}); ok {
+			items[i]["api-url"] = profile["api-url"]
+			items[i]["default-account"] = profile["default-account"]
+		}
+	}
+
+	if err := printer.print(profileColumns, items); err != nil {
+		ExitWithError(ExitError, fmt.Errorf("Could not print profiles\n%s", err))
+	}
+}
+
+// useProfileCommand sets the profile as the current one in the configuration file
+func useProfileCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Profile name is required"))
+	}
+	name := args[0]
+	if _, found := readProfiles()[name]; !found {
+		ExitWithError(ExitBadArgs, &config.ProfileNotFoundError{Name: name})
+	}
+
+	updateConfigFile(func(settings map[interface{}]interface{}) {
+		settings["current-profile"] = name
+	})
+	ExitWithSuccess(fmt.Sprintf("Switched to profile '%s'", name))
+}
+
+// configSetCommand sets the key in the selected profile, creating it if needed, or at the top level
+func configSetCommand(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Key and value are required"))
+	}
+	key, value := args[0], args[1]
+	if key == "profiles" || strings.HasPrefix(key, "profiles.") || key == "current-profile" {
+		ExitWithError(ExitBadArgs, fmt.Errorf("%s: use --profile to set keys in a profile and 'outlyer config use-profile' to select it", key))
+	}
+
+	profile := config.ProfileName()
+	updateConfigFile(func(settings map[interface{}]interface{}) {
+		if profile != "" {
+			config.SetValue(settings, "profiles."+profile+"."+key, value)
+		} else {
+			config.SetValue(settings, key, value)
+		}
+	})
+
+	if profile != "" {
+		ExitWithSuccess(fmt.Sprintf("Set %s in profile '%s'", key, profile))
+	}
+	ExitWithSuccess(fmt.Sprintf("Set %s", key))
+}
+
+// readProfiles reads the profiles defined in the configuration file
+func readProfiles() map[interface{}]interface{} {
+	settings, err := config.ReadFile()
+	if err != nil {
+		ExitWithError(ExitError, fmt.Errorf("Could not read the configuration file\n%s", err))
+	}
+	profiles, _ := config.GetValue(settings, "profiles")
+	profilesMap, _ := profiles.(map[interface{}]interface{})
+	return profilesMap
+}
+
+// updateConfigFile reads the configuration file, updates its settings and writes it back
+func updateConfigFile(update func(settings map[interface{}]interface{})) {
+	settings, err := config.ReadFile()
+	if err != nil {
+		ExitWithError(ExitError, fmt.Errorf("Could not read the configuration file\n%s", err))
+	}
+	update(settings)
+	if err := config.WriteFile(settings); err != nil {
+		ExitWithError(ExitError, fmt.Errorf("Could not write the configuration file\n%s", err))
+	}
+}
+
+// getAccount returns the account set with the --account flag, or otherwise the default account
+// set with the OUTLYER_ACCOUNT environment variable, the selected profile or the configuration file
+func getAccount(cmd *cobra.Command) string {
+	if account := cmd.PersistentFlags().Lookup("account").Value.String(); account != "" {
+		return account
+	}
+	return config.CLI.GetString("default-account")
+}
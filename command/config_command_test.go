@@ -0,0 +1,70 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/outlyerapp/outlyer-cli/config"
+	"github.com/spf13/cobra"
+)
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer config.CLI.SetConfigFile("")
+
+	path := filepath.Join(dir, "outlyer.yaml")
+	content := "api-token: file-token\ndefault-account: file-account\nprofiles:\n  staging:\n    api-token: profile-token\n    default-account: profile-account\n"
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		env         map[string]string
+		wantAccount string
+		wantToken   string
+	}{
+		{"configuration file", []string{"--config", path}, nil, "file-account", "file-token"},
+		{"profile", []string{"--config", path}, map[string]string{config.ProfileEnv: "staging"}, "profile-account", "profile-token"},
+		{"environment", []string{"--config", path},
+			map[string]string{config.ProfileEnv: "staging", config.AccountEnv: "env-account", config.APITokenEnv: "env-token"}, "env-account", "env-token"},
+		{"flag", []string{"--config", path, "--account", "flag-account"},
+			map[string]string{config.ProfileEnv: "staging", config.AccountEnv: "env-account"}, "flag-account", "profile-token"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range []string{config.ProfileEnv, config.AccountEnv, config.APITokenEnv} {
+				os.Unsetenv(name)
+			}
+			for name, value := range test.env {
+				os.Setenv(name, value)
+				defer os.Unsetenv(name)
+			}
+
+			cmd := &cobra.Command{Use: "get"}
+			cmd.Flags().String("config", "", "")
+			cmd.PersistentFlags().StringP("account", "a", "", "")
+			if err := cmd.ParseFlags(test.args); err != nil {
+				t.Fatal(err)
+			}
+			LoadConfig(cmd)
+
+			if got := getAccount(cmd); got != test.wantAccount {
+				t.Errorf("getAccount() = %v, want %v", got, test.wantAccount)
+			}
+			if got := config.CLI.GetString("api-token"); got != test.wantToken {
+				t.Errorf("api-token = %v, want %v", got, test.wantToken)
+			}
+			if got := config.FilePath(); got != path {
+				t.Errorf("FilePath() = %v, want %v", got, path)
+			}
+		})
+	}
+}
@@ -3,14 +3,15 @@ package command
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"log"
 	"os"
-	"os/user"
 	"strings"
 
 	"github.com/outlyerapp/outlyer-cli/api"
 	"github.com/outlyerapp/outlyer-cli/config"
+	"github.com/outlyerapp/outlyer-cli/credentials"
+	"github.com/outlyerapp/outlyer-cli/terminal"
 	"github.com/spf13/cobra"
 	yaml "gopkg.in/yaml.v2"
 )
@@ -21,52 +22,177 @@ func NewConfigureCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "configure",
 		Short: "Set up the Outlyer CLI by validating your API token",
-		Run:   createLocalConfig,
+		Example: `
+Asks for your API token, validates it and saves it:
+$ outlyer configure
+
+Creates or updates the staging profile with its own API token:
+$ outlyer configure --profile=staging
+
+Configures the Outlyer CLI from a CI pipeline, reading the API token from stdin:
+$ echo "$OUTLYER_TOKEN" | outlyer configure --token-stdin
+
+Saves the API token encrypted with a passphrase, which is asked whenever the token is used
+unless it's set with the ` + credentials.PassphraseEnv + ` environment variable:
+$ outlyer configure --encrypt`,
+		Annotations: map[string]string{writesConfig: "true"},
+		Run:         createLocalConfig,
 	}
-	return cmd
-}
 
-type cliConfig struct {
-	APIToken string `yaml:"api-token"`
+	cmd.PersistentFlags().String("token", "", "(Optional) API token to validate and save. It may be visible to other users in the process list, prefer --token-stdin")
+	cmd.PersistentFlags().Bool("token-stdin", false, "(Optional) Reads the API token to validate and save from stdin")
+	cmd.PersistentFlags().Bool("encrypt", false, "(Optional) Saves the API token encrypted with a passphrase rather than in plaintext")
+	return cmd
 }
 
-// createLocalConfig validates the API token provided by the user
-// and persists it locally by creating the a hidden Outlyer yaml configuration file
-// at the user's $HOME directory.
+// createLocalConfig validates the API token provided by the user and persists it locally
+// in the Outlyer yaml configuration file, keeping any other settings and profiles it has
 func createLocalConfig(cmd *cobra.Command, args []string) {
-	for i := 0; i < 3; i++ {
-		// Reads the API token from stdin
-		reader := bufio.NewReader(os.Stdin)
-		fmt.Print("Please enter your API token: ")
-		apiToken, _ := reader.ReadString('\n')
-		apiToken = strings.Replace(apiToken, "\n", "", -1) // removes return character on *unix and darwin
-		apiToken = strings.Replace(apiToken, "\r", "", -1) // removes return character on windows
+	apiToken := cmd.PersistentFlags().Lookup("token").Value.String()
+	tokenStdin, _ := cmd.PersistentFlags().GetBool("token-stdin")
+	encrypt, _ := cmd.PersistentFlags().GetBool("encrypt")
+	if apiToken != "" && tokenStdin {
+		ExitWithError(ExitBadArgs, fmt.Errorf("--token and --token-stdin can't be used together"))
+	}
 
-		config.CLI.Set("api-token", apiToken)
+	// Only an interactive user can be asked again for the API token if it's invalid
+	interactive := apiToken == "" && !tokenStdin && terminal.IsTerminal(os.Stdin)
+	attempts := 1
+	if interactive {
+		attempts = 3
+	}
 
-		_, err := api.Get("/user")
-		if err != nil {
-			fmt.Fprintln(os.Stderr, fmt.Errorf("Error: invalid API token\n%s", err))
-			continue
+	for i := 0; i < attempts; i++ {
+		if interactive {
+			fmt.Fprint(os.Stderr, "Please enter your API token: ")
+			token, err := terminal.ReadPassword(os.Stdin)
+			fmt.Fprintln(os.Stderr, "")
+			if err != nil {
+				ExitWithError(ExitError, fmt.Errorf("Could not read the API token\n%s", err))
+			}
+			apiToken = strings.TrimSpace(string(token))
+		} else {
+			token, err := getToken(apiToken, os.Stdin)
+			if err != nil {
+				ExitWithError(ExitError, err)
+			}
+			apiToken = token
+		}
+		if apiToken == "" {
+			ExitWithError(ExitBadArgs, fmt.Errorf("API token is required"))
 		}
 
-		userConfig := cliConfig{apiToken}
-
-		userConfigInBytes, err := yaml.Marshal(userConfig)
+		user, accounts, err := validateToken(apiToken)
+		if api.IsAuthError(err) && i < attempts-1 {
+			fmt.Fprintln(os.Stderr, "Error: invalid API token, please try again")
+			continue
+		}
+		if api.IsAuthError(err) {
+			ExitWithError(ExitAuthFailure, fmt.Errorf("invalid API token"))
+		}
 		if err != nil {
 			ExitWithError(ExitError, err)
 		}
 
-		user, err := user.Current()
-		if err != nil {
-			ExitWithError(ExitError, err)
+		token, encrypted := apiToken, false
+		if encrypt {
+			token, encrypted = encryptToken(apiToken), true
 		}
+		profile := config.ProfileName()
+		updateConfigFile(func(settings map[interface{}]interface{}) {
+			setToken(settings, profile, token, encrypted)
+		})
 
-		err = ioutil.WriteFile(user.HomeDir+"/.outlyer.yaml", userConfigInBytes, 0644)
-		if err != nil {
-			log.Fatalln(err)
+		printValidation(user, accounts)
+		if profile != "" {
+			ExitWithSuccess(fmt.Sprintf("\nSuccess! Outlyer CLI profile '%s' is configured in %s and ready to use", profile, config.FilePath()))
 		}
-		ExitWithSuccess("Success! Outlyer CLI is configured and ready to use")
+		ExitWithSuccess(fmt.Sprintf("\nSuccess! Outlyer CLI is configured in %s and ready to use", config.FilePath()))
+	}
+}
+
+// getToken returns the API token set with --token or, if there is none, reads it from the input, which is
+// stdin when --token-stdin is set or stdin is not a terminal. Surrounding whitespace and line breaks are ignored.
+func getToken(flagToken string, input io.Reader) (string, error) {
+	if flagToken != "" {
+		return flagToken, nil
+	}
+	content, err := ioutil.ReadAll(bufio.NewReader(input))
+	if err != nil {
+		return "", fmt.Errorf("Could not read the API token from stdin\n%s", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// setToken sets the API token of the profile, or the top-level one if no profile is given, keeping all other
+// settings. Only one of the plaintext and the encrypted API token is kept, so they can't get out of sync.
+func setToken(settings map[interface{}]interface{}, profile, token string, encrypted bool) {
+	key, staleKey := "api-token", "encrypted-api-token"
+	if encrypted {
+		key, staleKey = "encrypted-api-token", "api-token"
+	}
+	prefix := ""
+	if profile != "" {
+		prefix = "profiles." + profile + "."
+	}
+	config.SetValue(settings, prefix+key, token)
+	config.DeleteValue(settings, prefix+staleKey)
+}
+
+// encryptToken encrypts the API token with a passphrase the user is asked for twice, unless it's set
+// with the OUTLYER_TOKEN_PASSPHRASE environment variable
+func encryptToken(apiToken string) string {
+	passphrase, err := credentials.AskPassphrase(true)
+	if err != nil {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Could not read the passphrase\n%s", err))
+	}
+	encrypted, err := credentials.Encrypt(apiToken, passphrase)
+	if err != nil {
+		ExitWithError(ExitError, fmt.Errorf("Could not encrypt the API token\n%s", err))
+	}
+	return encrypted
+}
+
+// validateToken fetches the user and the accounts the API token grants access to. The error tells
+// an invalid API token, which api.IsAuthError reports, apart from failing to reach the Outlyer API.
+func validateToken(apiToken string) (map[string]interface{}, []map[string]interface{}, error) {
+	credentials.SetToken(apiToken)
+
+	resp, err := api.Get("/user")
+	if api.IsAuthError(err) {
+		return nil, nil, err
+	}
+	if _, isHTTPError := err.(*api.HTTPError); isHTTPError {
+		return nil, nil, fmt.Errorf("Could not validate the API token, the Outlyer API returned an error\n%s", err)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("Could not reach the Outlyer API at %s\n%s", config.CLI.GetString("api-url"), err)
+	}
+	user := make(map[string]interface{})
+	yaml.Unmarshal(resp, &user)
+
+	resp, err = api.Get("/accounts")
+	if err != nil {
+		return nil, nil, fmt.Errorf("Could not fetch the accounts the API token grants access to\n%s", err)
+	}
+	var accounts []map[string]interface{}
+	yaml.Unmarshal(resp, &accounts)
+	return user, accounts, nil
+}
+
+// printValidation prints the user the API token belongs to and the accounts it grants access to
+func printValidation(user map[string]interface{}, accounts []map[string]interface{}) {
+	name := fmt.Sprint(user["name"])
+	if email, ok := user["email"]; ok {
+		name = fmt.Sprintf("%s <%s>", name, email)
+	}
+	fmt.Printf("API token is valid for %s\n", name)
+
+	if len(accounts) == 0 {
+		fmt.Println("\nThe API token doesn't grant access to any account")
+		return
 	}
-	ExitWithError(ExitError, fmt.Errorf("Could not configure Outlyer CLI. Please contact Outlyer support"))
+	fmt.Print("\nThe API token grants access to the following accounts:\n\n")
+	printer, _ := parseOutput(outputTable)
+	printer.print(accountColumns, accounts)
 }
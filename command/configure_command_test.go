@@ -0,0 +1,76 @@
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		flagToken string
+		input     string
+		want      string
+	}{
+		{"token flag", "flag-token", "stdin-token\n", "flag-token"},
+		{"token from stdin", "", "  stdin-token\r\n", "stdin-token"},
+		{"empty stdin", "", "\n", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := getToken(test.flagToken, strings.NewReader(test.input))
+			if err != nil || got != test.want {
+				t.Errorf("getToken() = %q, %v, want %q", got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestSetToken(t *testing.T) {
+	newSettings := func() map[interface{}]interface{} {
+		return map[interface{}]interface{}{
+			"api-token":           "old-token",
+			"api-url":             "https://api.example.com",
+			"encrypted-api-token": "stale",
+			"headers":             map[interface{}]interface{}{"common": map[interface{}]interface{}{"x-team": "ops"}},
+			"profiles":            map[interface{}]interface{}{"staging": map[interface{}]interface{}{"default-account": "acme-staging"}},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		profile   string
+		encrypted bool
+		want      map[interface{}]interface{}
+	}{
+		{"top-level", "", false, map[interface{}]interface{}{
+			"api-token": "new-token",
+			"api-url":   "https://api.example.com",
+			"headers":   map[interface{}]interface{}{"common": map[interface{}]interface{}{"x-team": "ops"}},
+			"profiles":  map[interface{}]interface{}{"staging": map[interface{}]interface{}{"default-account": "acme-staging"}},
+		}},
+		{"encrypted", "", true, map[interface{}]interface{}{
+			"api-url":             "https://api.example.com",
+			"encrypted-api-token": "new-token",
+			"headers":             map[interface{}]interface{}{"common": map[interface{}]interface{}{"x-team": "ops"}},
+			"profiles":            map[interface{}]interface{}{"staging": map[interface{}]interface{}{"default-account": "acme-staging"}},
+		}},
+		{"profile", "staging", false, map[interface{}]interface{}{
+			"api-token":           "old-token",
+			"api-url":             "https://api.example.com",
+			"encrypted-api-token": "stale",
+			"headers":             map[interface{}]interface{}{"common": map[interface{}]interface{}{"x-team": "ops"}},
+			"profiles":            map[interface{}]interface{}{"staging": map[interface{}]interface{}{"default-account": "acme-staging", "api-token": "new-token"}},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settings := newSettings()
+			setToken(settings, test.profile, "new-token", test.encrypted)
+			if !reflect.DeepEqual(settings, test.want) {
+				t.Errorf("setToken() = %v, want %v", settings, test.want)
+			}
+		})
+	}
+}
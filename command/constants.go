@@ -12,3 +12,16 @@ const (
 	//Views represents the views resource name in Outlyer
 	Views = "views"
 )
+
+// resourceTypes lists all resource names supported by the Outlyer CLI
+var resourceTypes = []string{Alerts, Checks, Dashboards, Plugins, Views}
+
+// isResourceType checks whether the given name is a resource name like 'alerts'
+func isResourceType(name string) bool {
+	for _, resourceType := range resourceTypes {
+		if name == resourceType {
+			return true
+		}
+	}
+	return false
+}
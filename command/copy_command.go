@@ -0,0 +1,210 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/outlyerapp/outlyer-cli/api"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// NewCopyCommand creates a Command for copying resources from one Outlyer account to another
+func NewCopyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "copy .|[resource]|[resource/name]",
+		Short: "Copies resources from one account to another, creating or updating them in the destination account. The available resources are: alerts, checks, dashboards, plugins and views",
+		Example: `
+Copies the docker dashboard from the staging account to the prod account:
+$ outlyer copy dashboards/docker --from-account=staging --to-account=prod
+
+Copies all alerts and checks, along with the docker plugin, from staging to prod:
+$ outlyer copy alerts checks plugins/docker.py --from-account=staging --to-account=prod
+
+Copies all resources to a sandbox account prefixing their names, so they don't overwrite the existing ones.
+References between the copied resources, like an alert evaluating a copied check, use the new names:
+$ outlyer copy . --from-account=prod --to-account=sandbox --prefix=prod-
+
+Shows the differences with the destination account without copying anything:
+$ outlyer copy dashboards --from-account=staging --to-account=prod --dry-run`,
+		Run: copyCommand,
+	}
+
+	cmd.PersistentFlags().String("from-account", "", "Account to copy the resources from")
+	cmd.PersistentFlags().String("to-account", "", "Account to copy the resources to")
+	cmd.PersistentFlags().String("prefix", "", "(Optional) Prefix to add to the names of the copied resources")
+	cmd.PersistentFlags().String("suffix", "", "(Optional) Suffix to add to the names of the copied resources, before the extension of plugins")
+	cmd.PersistentFlags().Bool("dry-run", false, "(Optional) Shows the differences with the destination account without copying any resources")
+	cmd.PersistentFlags().Int("parallelism", 10, "(Optional) Maximum number of concurrent requests to the Outlyer API. Can also be set with the 'parallelism' configuration")
+	cmd.PersistentFlags().BoolP("yes", "y", false, "(Optional) Copies without asking for confirmation. Can also be set with the "+assumeYesEnv+" environment variable")
+	return cmd
+}
+
+// copyCommand fetches the resources from the source account and applies them to the destination account
+// through the same plan, confirmation and results as apply, after showing their differences
+func copyCommand(cmd *cobra.Command, args []string) {
+	printer := newPrinter()
+	from := cmd.PersistentFlags().Lookup("from-account").Value.String()
+	to := cmd.PersistentFlags().Lookup("to-account").Value.String()
+	if from == "" || to == "" {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Both --from-account and --to-account are required"))
+	}
+
+	if len(args) < 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Resource is required"))
+	}
+	for _, arg := range args {
+		if arg != "." && !isResourceType(arg) && !isResourceType(arg[:strings.Index(arg+"/", "/")]) {
+			ExitWithError(ExitBadArgs, fmt.Errorf("%s: resources must be specified like 'alerts' or 'alerts/docker'", arg))
+		}
+	}
+
+	prefix := cmd.PersistentFlags().Lookup("prefix").Value.String()
+	suffix := cmd.PersistentFlags().Lookup("suffix").Value.String()
+	if from == to && prefix == "" && suffix == "" {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Resources can only be copied to the same account with a --prefix or a --suffix"))
+	}
+
+	definitions := fetchCopiedDefinitions(from, args)
+	resources, err := getCopiedResources(definitions, prefix, suffix)
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+
+	pool := newWorkerPool(cmd)
+	pool.run(len(resources), func(i int) string {
+		if resources[i].err != nil {
+			return "skipped " + resources[i].getTypeAndNameWithExtension()
+		}
+		diff(to, &resources[i])
+		return "compared " + resources[i].getTypeAndNameWithExtension()
+	})
+
+	dryRun, _ := cmd.PersistentFlags().GetBool("dry-run")
+	var out io.Writer = os.Stderr
+	if dryRun {
+		out = printer.messages()
+	}
+	fmt.Fprintf(out, "\nCopying %d resources from account '%s' to account '%s'\n\n", len(resources), from, to)
+	for _, resource := range resources {
+		if resource.diff != "" {
+			fmt.Fprintln(out, resource.diff)
+		}
+	}
+	if dryRun {
+		showPlan(printer, to, resources)
+		fmt.Fprintln(printer.messages(), "\nDry run. 0 resources copied.")
+		return
+	}
+	printPlan(out, to, resources)
+	confirmAndApply(cmd, printer, pool, to, resources)
+}
+
+// copiedDefinition is the export view of a resource to copy along with its type
+type copiedDefinition struct {
+	resourceType string
+	definition   map[interface{}]interface{}
+}
+
+// fetchCopiedDefinitions fetches the export view of the given resources from the account.
+// Arguments may be '.' for all resources, a resource type or a single resource.
+func fetchCopiedDefinitions(account string, args []string) []copiedDefinition {
+	for _, arg := range args {
+		if arg == "." {
+			args = resourceTypes
+			break
+		}
+	}
+
+	var definitions []copiedDefinition
+	for _, arg := range removeDuplicates(args) {
+		if isResourceType(arg) {
+			typeDefinitions, err := fetchDefinitions(account, arg)
+			if err != nil {
+				ExitWithError(getExitCode(err), fmt.Errorf("Could not fetch %s from account %s\n%s", arg, account, err))
+			}
+			for _, definition := range typeDefinitions {
+				// The list of plugins can't be relied on to include their content, so each one is fetched by itself
+				if arg == Plugins {
+					definition = fetchCopiedDefinition(account, Plugins+"/"+fmt.Sprint(definition["name"]))
+				}
+				definitions = append(definitions, copiedDefinition{resourceType: arg, definition: definition})
+			}
+			continue
+		}
+
+		resourceType, _ := splitAPIPath(arg)
+		definitions = append(definitions, copiedDefinition{resourceType: resourceType, definition: fetchCopiedDefinition(account, arg)})
+	}
+	return definitions
+}
+
+// fetchCopiedDefinition fetches the export view of a single resource, like 'plugins/docker.py', from the account
+func fetchCopiedDefinition(account, apiPath string) map[interface{}]interface{} {
+	resp, err := api.Get(accountPath(account, apiPath) + "?view=export")
+	if api.IsNotFound(err) {
+		ExitWithError(ExitError, fmt.Errorf("%s: not found in account %s", apiPath, account))
+	}
+	if err != nil {
+		ExitWithError(getExitCode(err), fmt.Errorf("Could not fetch %s from account %s\n%s", apiPath, account, err))
+	}
+	definition := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal(resp, &definition); err != nil {
+		ExitWithError(ExitError, fmt.Errorf("Could not read %s from account %s\n%s", apiPath, account, err))
+	}
+	return definition
+}
+
+// getCopiedResources converts the definitions fetched from the account to resources to apply, renaming
+// them with the prefix and suffix along with the references between them. Resources are given a path
+// like 'alerts/docker.yaml', as if they had been exported from the source account.
+func getCopiedResources(definitions []copiedDefinition, prefix, suffix string) ([]resource, error) {
+	renamed := make(map[string]string)
+	for _, copied := range definitions {
+		resourceType, name := copied.resourceType, fmt.Sprint(copied.definition["name"])
+		if _, found := renamed[resourceType+"/"+name]; found {
+			return nil, fmt.Errorf("%s/%s is included twice", resourceType, name)
+		}
+		renamed[resourceType+"/"+name] = renameResource(name, resourceType, prefix, suffix)
+	}
+
+	resources := make([]resource, len(definitions))
+	for i, copied := range definitions {
+		resourceType, definition := copied.resourceType, copied.definition
+		name := renamed[resourceType+"/"+fmt.Sprint(definition["name"])]
+
+		var err error
+		res := resource{path: resourceType + "/" + getResourceFileName(resourceType, name), name: name, status: "FAIL"}
+		if resourceType == Plugins {
+			// Copying a plugin without its content would overwrite the script in the destination account
+			content, isString := definition["content"].(string)
+			if !isString || content == "" {
+				res.err = fmt.Errorf("the plugin has no content in the source account")
+				resources[i] = res
+				continue
+			}
+			res.bytes, err = yaml.Marshal(&plugin{Content: content, Name: name, Encoding: "base64"})
+		} else {
+			renameReferences(definition, "", renamed)
+			definition["name"] = name
+			res.bytes, err = yaml.Marshal(definition)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Could not copy %s/%s\n%s", resourceType, name, err)
+		}
+		resources[i] = res
+	}
+	return resources, nil
+}
+
+// renameResource adds the prefix and the suffix to the name of the resource, keeping the extension of plugins last
+func renameResource(name, resourceType, prefix, suffix string) string {
+	extension := ""
+	if resourceType == Plugins {
+		extension = path.Ext(name)
+	}
+	return prefix + strings.TrimSuffix(name, extension) + suffix + extension
+}
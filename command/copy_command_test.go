@@ -0,0 +1,77 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestRenameResource(t *testing.T) {
+	tests := []struct {
+		name, resourceType, want string
+	}{
+		{"docker", Alerts, "stg-docker-v2"},
+		{"docker.py", Plugins, "stg-docker-v2.py"},
+		{"docker.yaml", Dashboards, "stg-docker.yaml-v2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := renameResource(test.name, test.resourceType, "stg-", "-v2"); got != test.want {
+				t.Errorf("renameResource() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetCopiedResources(t *testing.T) {
+	var definitions []copiedDefinition
+	for _, copied := range []struct{ resourceType, definition string }{
+		{Alerts, "name: docker\ncriteria:\n- check: docker\n- check: kafka"},
+		{Checks, "name: docker\ncommand: python  /opt/docker.py --all"},
+		{Plugins, "name: docker.py\ncontent: cHJpbnQoImhpIik=\nencoding: base64"},
+	} {
+		definition := make(map[interface{}]interface{})
+		yaml.Unmarshal([]byte(copied.definition), &definition)
+		definitions = append(definitions, copiedDefinition{resourceType: copied.resourceType, definition: definition})
+	}
+
+	resources, err := getCopiedResources(definitions, "stg-", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, resource := range resources {
+		got[resource.path] = string(resource.bytes)
+	}
+	want := map[string]string{
+		// Only the references to copied resources are renamed
+		"alerts/stg-docker.yaml": "criteria:\n- check: stg-docker\n- check: kafka\nname: stg-docker\n",
+		"checks/stg-docker.yaml": "command: python /opt/stg-docker.py --all\nname: stg-docker\n",
+		"plugins/stg-docker.py":  "content: cHJpbnQoImhpIik=\nencoding: base64\nname: stg-docker.py\n",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getCopiedResources() = %v, want %v", got, want)
+	}
+	for i, want := range []string{"alerts/stg-docker", "checks/stg-docker", "plugins/stg-docker.py"} {
+		if apiPath := resources[i].getAPIPath(); apiPath != want {
+			t.Errorf("resource.getAPIPath() = %v, want %v", apiPath, want)
+		}
+	}
+}
+
+func TestGetCopiedResourcesWithoutPluginContent(t *testing.T) {
+	definitions := []copiedDefinition{
+		{resourceType: Plugins, definition: map[interface{}]interface{}{"name": "docker.py"}},
+		{resourceType: Plugins, definition: map[interface{}]interface{}{"name": "kafka.py", "content": 12}},
+	}
+	resources, err := getCopiedResources(definitions, "", "-v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, resource := range resources {
+		if resource.err == nil || resource.bytes != nil {
+			t.Errorf("getCopiedResources() copied %s without content", resource.path)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/outlyerapp/outlyer-cli/config"
+	"github.com/spf13/cobra"
+)
+
+// NewDeleteCommand creates a Command for deleting resources from the user's Outlyer account
+func NewDeleteCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete [resource/name]...",
+		Short: "Deletes resources from the specified account. The available resources are: alerts, checks, dashboards, plugins and views",
+		Example: `
+Deletes the docker alert and the elasticsearch plugin from the account:
+$ outlyer delete alerts/docker plugins/elasticsearch.py --account=<your_account>
+
+Deletes all dashboards and views from the account:
+$ outlyer delete --all-of-type=dashboards --all-of-type=views --account=<your_account>
+
+Before deleting, resources that reference the ones being deleted (like alerts evaluating a deleted check)
+are listed since they may stop working.`,
+		Run: deleteCommand,
+	}
+
+	cmd.PersistentFlags().StringP("account", "a", "", "User account to use. Required unless a default account is set with the 'default-account' configuration or the "+config.AccountEnv+" environment variable")
+	cmd.PersistentFlags().StringSlice("all-of-type", []string{}, "(Optional) Deletes all resources of the given type")
+	cmd.PersistentFlags().BoolP("yes", "y", false, "(Optional) Deletes without asking for confirmation. Can also be set with the "+assumeYesEnv+" environment variable")
+	return cmd
+}
+
+// deleteCommand validates the resources to delete, warns about the resources
+// referencing them and deletes them once confirmed by the user
+func deleteCommand(cmd *cobra.Command, args []string) {
+	printer := newPrinter()
+	account := getAccount(cmd)
+	if account == "" {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Account is required"))
+	}
+
+	allOfType, _ := cmd.PersistentFlags().GetStringSlice("all-of-type")
+	if len(args) < 1 && len(allOfType) < 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Resource is required"))
+	}
+
+	var targets []string
+	for _, arg := range args {
+		slashIndex := strings.Index(arg, "/")
+		if slashIndex == -1 || !isResourceType(arg[:slashIndex]) || slashIndex == len(arg)-1 {
+			ExitWithError(ExitBadArgs, fmt.Errorf("%s: resources must be specified like 'alerts/docker'", arg))
+		}
+		targets = append(targets, arg)
+	}
+	for _, resourceType := range allOfType {
+		if !isResourceType(resourceType) {
+			ExitWithError(ExitBadArgs, fmt.Errorf("%s: unknown resource type", resourceType))
+		}
+		names, err := listResourceNames(account, resourceType)
+		if err != nil {
+			ExitWithError(getExitCode(err), fmt.Errorf("Could not fetch %s from account %s\n%s", resourceType, account, err))
+		}
+		targets = append(targets, names...)
+	}
+	targets = removeDuplicates(targets)
+
+	if len(targets) == 0 {
+		ExitWithSuccess("No resources found. 0 resources deleted.")
+	}
+
+	dependents, err := findDependents(account, targets)
+	if err != nil {
+		ExitWithError(getExitCode(err), fmt.Errorf("Could not find resources depending on the ones to delete from account %s\n%s", account, err))
+	}
+
+	fmt.Fprintf(os.Stderr, "\nResources to delete from account '%s'...\n\n", account)
+	resources := make([]resource, len(targets))
+	for i, target := range targets {
+		fmt.Fprintf(os.Stderr, "\t- %s\n", target)
+		_, name := splitAPIPath(target)
+		resources[i] = resource{path: target, name: name, status: "FAIL", action: actionDelete}
+	}
+
+	if len(dependents) > 0 {
+		var names []string
+		for name := range dependents {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Fprintf(os.Stderr, "\nWarning: the following resources reference resources being deleted and may break...\n\n")
+		for _, name := range names {
+			fmt.Fprintf(os.Stderr, "\t! %s -> %s\n", name, strings.Join(dependents[name], ", "))
+		}
+	}
+
+	if askForConfirmation(cmd, fmt.Sprintf("Are you sure you want to delete from account '%s'?", account)) {
+		applyResources(newWorkerPool(cmd), account, resources)
+		printResults(printer, account, resources)
+		exitWithResults(resources)
+	} else {
+		fmt.Fprintln(printer.messages(), "Skipping delete. 0 resources deleted.")
+	}
+}
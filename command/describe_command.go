@@ -0,0 +1,228 @@
+package command
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/outlyerapp/outlyer-cli/api"
+	"github.com/outlyerapp/outlyer-cli/config"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// NewDescribeCommand creates a Command for describing a single resource and its relationships
+func NewDescribeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "describe [resource/name]",
+		Short: "Shows the definition of a resource and the resources related to it. The available resources are: alerts, checks, dashboards, plugins and views",
+		Example: `
+Shows the docker alert with the checks it evaluates, the plugins those checks run, the resources
+referencing it and the dashboards showing the same metrics:
+$ outlyer describe alerts/docker --account=<your_account>
+
+Shows the checks running the docker plugin:
+$ outlyer describe plugins/docker.py --account=<your_account>`,
+		Run: describeCommand,
+	}
+
+	cmd.PersistentFlags().StringP("account", "a", "", "User account to use. Required unless a default account is set with the 'default-account' configuration or the "+config.AccountEnv+" environment variable")
+	return cmd
+}
+
+// describeCommand fetches the resource and all resources in the account to find how they are related
+func describeCommand(cmd *cobra.Command, args []string) {
+	printer := newPrinter()
+	account := getAccount(cmd)
+	if account == "" {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Account is required"))
+	}
+
+	if len(args) != 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("A single resource is required"))
+	}
+	name := args[0]
+	slashIndex := strings.Index(name, "/")
+	if slashIndex == -1 || !isResourceType(name[:slashIndex]) || slashIndex == len(name)-1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("%s: resources must be specified like 'alerts/docker'", name))
+	}
+
+	resp, err := api.Get(accountPath(account, name) + "?view=export")
+	if api.IsNotFound(err) {
+		ExitWithError(ExitError, fmt.Errorf("%s: not found in account %s", name, account))
+	}
+	if err != nil {
+		ExitWithError(getExitCode(err), fmt.Errorf("Could not fetch %s from account %s\n%s", name, account, err))
+	}
+	definition := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal(resp, &definition); err != nil {
+		ExitWithError(ExitError, fmt.Errorf("Could not read %s from account %s\n%s", name, account, err))
+	}
+	if name[:slashIndex] == Plugins { // Shows the plugin script rather than its encoded content
+		if content, ok := definition["content"].(string); ok {
+			if script, err := base64.StdEncoding.DecodeString(content); err == nil {
+				definition["content"] = string(script)
+				delete(definition, "encoding")
+			}
+		}
+	}
+
+	definitions, err := fetchAllDefinitions(account)
+	if err != nil {
+		ExitWithError(getExitCode(err), fmt.Errorf("Could not fetch resources from account %s\n%s", account, err))
+	}
+	plugins, err := listResourceNames(account, Plugins)
+	if err != nil {
+		ExitWithError(getExitCode(err), fmt.Errorf("Could not fetch %s from account %s\n%s", Plugins, account, err))
+	}
+	exists := make(map[string]bool)
+	for existing := range definitions {
+		exists[existing] = true
+	}
+	for _, plugin := range plugins {
+		exists[plugin] = true
+	}
+
+	description := describe(name, definition, definitions, exists)
+	description["account"] = account
+
+	if !printer.isTable() {
+		if err := printer.printObject(description); err != nil {
+			ExitWithError(ExitError, fmt.Errorf("Could not print %s\n%s", name, err))
+		}
+		return
+	}
+	printDescription(os.Stdout, description)
+}
+
+// describe returns the definition of the resource along with the resources it references, directly
+// or through other resources, the resources referencing it and the dashboards showing the same metrics
+func describe(name string, definition map[interface{}]interface{}, definitions map[string]map[interface{}]interface{}, exists map[string]bool) map[string]interface{} {
+	visited := map[string]bool{name: true}
+	references := getReferenceTree(definition, definitions, exists, visited)
+
+	var referencedBy []string
+	for dependent := range getDependents(definitions, []string{name}) {
+		referencedBy = append(referencedBy, dependent)
+	}
+	sort.Strings(referencedBy)
+
+	// The metrics of the resource include the ones of the resources it references,
+	// so dashboards showing the metrics of the check an alert evaluates are related to the alert
+	metrics := make(map[string]bool)
+	for related := range visited {
+		relatedDefinition := definitions[related]
+		if related == name {
+			relatedDefinition = definition
+		}
+		for _, metric := range extractMetrics(relatedDefinition) {
+			metrics[metric] = true
+		}
+	}
+
+	var dashboards []map[string]interface{}
+	for _, dashboard := range sortedDefinitionNames(definitions) {
+		if dashboard == name || !strings.HasPrefix(dashboard, Dashboards+"/") {
+			continue
+		}
+		var shared []string
+		for _, metric := range extractMetrics(definitions[dashboard]) {
+			if metrics[metric] {
+				shared = append(shared, metric)
+			}
+		}
+		if len(shared) > 0 {
+			dashboards = append(dashboards, map[string]interface{}{"name": dashboard, "metrics": shared})
+		}
+	}
+
+	return map[string]interface{}{
+		"name":          name,
+		"definition":    definition,
+		"references":    references,
+		"referenced_by": referencedBy,
+		"dashboards":    dashboards,
+	}
+}
+
+// getReferenceTree returns the resources referenced by the definition, each one with the resources it
+// references in turn. Resources already in the tree are not expanded again, so circular references end.
+func getReferenceTree(definition map[interface{}]interface{}, definitions map[string]map[interface{}]interface{}, exists, visited map[string]bool) []map[string]interface{} {
+	var tree []map[string]interface{}
+	for _, reference := range extractReferences(definition) {
+		node := map[string]interface{}{"name": reference, "found": exists[reference]}
+		if !visited[reference] {
+			visited[reference] = true
+			if referencedDefinition, found := definitions[reference]; found {
+				if children := getReferenceTree(referencedDefinition, definitions, exists, visited); len(children) > 0 {
+					node["references"] = children
+				}
+			}
+		}
+		tree = append(tree, node)
+	}
+	return tree
+}
+
+// sortedDefinitionNames returns the names of the definitions sorted
+func sortedDefinitionNames(definitions map[string]map[interface{}]interface{}) []string {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// printDescription prints the description of a resource in a human-readable layout
+func printDescription(out io.Writer, description map[string]interface{}) {
+	fmt.Fprintf(out, "Name:       %s\n", description["name"])
+	fmt.Fprintf(out, "Account:    %s\n", description["account"])
+
+	fmt.Fprintln(out, "\nDefinition:")
+	definitionInBytes, _ := yaml.Marshal(description["definition"])
+	for _, line := range splitLines(strings.TrimRight(string(definitionInBytes), "\n")) {
+		fmt.Fprintf(out, "  %s\n", line)
+	}
+
+	fmt.Fprintln(out, "\nReferences:")
+	references, _ := description["references"].([]map[string]interface{})
+	if len(references) == 0 {
+		fmt.Fprintln(out, "  <none>")
+	}
+	printReferenceTree(out, references, "  ")
+
+	fmt.Fprintln(out, "\nReferenced by:")
+	referencedBy, _ := description["referenced_by"].([]string)
+	if len(referencedBy) == 0 {
+		fmt.Fprintln(out, "  <none>")
+	}
+	for _, dependent := range referencedBy {
+		fmt.Fprintf(out, "  %s\n", dependent)
+	}
+
+	fmt.Fprintln(out, "\nDashboards showing the same metrics:")
+	dashboards, _ := description["dashboards"].([]map[string]interface{})
+	if len(dashboards) == 0 {
+		fmt.Fprintln(out, "  <none>")
+	}
+	for _, dashboard := range dashboards {
+		fmt.Fprintf(out, "  %s (%s)\n", dashboard["name"], strings.Join(dashboard["metrics"].([]string), ", "))
+	}
+}
+
+// printReferenceTree prints every reference indented under the resource referencing it
+func printReferenceTree(out io.Writer, tree []map[string]interface{}, indent string) {
+	for _, node := range tree {
+		if found, _ := node["found"].(bool); found {
+			fmt.Fprintf(out, "%s%s\n", indent, node["name"])
+		} else {
+			fmt.Fprintf(out, "%s%s (not found)\n", indent, node["name"])
+		}
+		children, _ := node["references"].([]map[string]interface{})
+		printReferenceTree(out, children, indent+"  ")
+	}
+}
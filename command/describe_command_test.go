@@ -0,0 +1,43 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestDescribe(t *testing.T) {
+	parse := func(definition string) map[interface{}]interface{} {
+		parsed := make(map[interface{}]interface{})
+		yaml.Unmarshal([]byte(definition), &parsed)
+		return parsed
+	}
+	definitions := map[string]map[interface{}]interface{}{
+		"alerts/docker":     parse("name: docker\ncriteria:\n- check: docker\n  metric: docker.cpu\n- check: missing"),
+		"checks/docker":     parse("name: docker\ncommand: docker.py\nalert: docker"),
+		"dashboards/docker": parse("name: docker\nwidgets:\n- metric: docker.cpu\n- metric: host.cpu"),
+		"dashboards/hosts":  parse("name: hosts\nwidgets:\n- metric: host.cpu"),
+	}
+	exists := map[string]bool{"alerts/docker": true, "checks/docker": true, "plugins/docker.py": true}
+
+	description := describe("alerts/docker", definitions["alerts/docker"], definitions, exists)
+
+	wantReferences := []map[string]interface{}{
+		{"name": "checks/docker", "found": true, "references": []map[string]interface{}{
+			{"name": "alerts/docker", "found": true},
+			{"name": "plugins/docker.py", "found": true},
+		}},
+		{"name": "checks/missing", "found": false},
+	}
+	if got := description["references"]; !reflect.DeepEqual(got, wantReferences) {
+		t.Errorf("describe() references = %v, want %v", got, wantReferences)
+	}
+	if got, want := description["referenced_by"], []string{"checks/docker"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("describe() referenced_by = %v, want %v", got, want)
+	}
+	wantDashboards := []map[string]interface{}{{"name": "dashboards/docker", "metrics": []string{"docker.cpu"}}}
+	if got := description["dashboards"]; !reflect.DeepEqual(got, wantDashboards) {
+		t.Errorf("describe() dashboards = %v, want %v", got, wantDashboards)
+	}
+}
@@ -0,0 +1,169 @@
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/outlyerapp/outlyer-cli/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	diffCreated    = "CREATED"
+	diffChanged    = "CHANGED"
+	diffUnchanged  = "UNCHANGED"
+	diffRemoteOnly = "REMOTE-ONLY"
+)
+
+// NewDiffCommand creates a Command for comparing local resources against the user's Outlyer account
+func NewDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff .|[folder]|[file]",
+		Short: "Shows the differences between local resources and the ones in the account. The available resources are: alerts, checks, dashboards, plugins and views",
+		Example: `
+Shows what would change by applying all resources from inside the 'demo' directory:
+$ outlyer diff . --account=<your_account>
+
+Shows what would change by applying only alerts and the elasticsearch plugin:
+$ outlyer diff path_to/demo/alerts path_to/demo/plugins/elasticsearch.py --account=<your_account>
+
+Shows what would change by applying all resources with the staging values:
+$ outlyer diff . --account=<your_account> --values=values/staging.yaml
+
+Fails with exit code 4 when the account has drifted from the local resources, which is useful in CI pipelines:
+$ outlyer diff . --account=<your_account> --exit-code`,
+		Run: diffCommand,
+	}
+
+	cmd.PersistentFlags().StringP("account", "a", "", "User account to use. Required unless a default account is set with the 'default-account' configuration or the "+config.AccountEnv+" environment variable")
+	cmd.PersistentFlags().StringArray("values", nil, "(Optional) YAML file with the values to render templated resources with, like {{ .Values.threshold }}. Resources are only rendered when values are given. Can be repeated, later files override earlier ones")
+	cmd.PersistentFlags().StringArray("set", nil, "(Optional) Sets a value to render templated resources with, like --set threshold=90. Can be repeated and overrides the values files")
+	cmd.PersistentFlags().Bool("exit-code", false, "(Optional) Exits with code 4 if there are any differences")
+	return cmd
+}
+
+// diffCommand compares each local resource with its remote version and prints a unified diff
+// for every resource that applying would create or update, followed by a summary of all resources
+func diffCommand(cmd *cobra.Command, args []string) {
+	printer := newPrinter()
+	account := getAccount(cmd)
+	if account == "" {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Account is required"))
+	}
+
+	if len(args) < 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Resource is required"))
+	}
+
+	resources := loadResources(args, getValues(cmd), false)
+
+	newWorkerPool(cmd).run(len(resources), func(i int) string {
+		diff(account, &resources[i])
+		return "compared " + resources[i].getTypeAndNameWithExtension()
+	})
+
+	// Resources present in the account but not locally are only reported for whole resource folders
+	var remoteOnly []resource
+	for _, apiPath := range getRemoteOnlyNames(args, resources, listRemoteNames(account)) {
+		_, name := splitAPIPath(apiPath)
+		remoteOnly = append(remoteOnly, resource{path: apiPath, name: name, status: diffRemoteOnly})
+	}
+	resources = append(resources, remoteOnly...)
+
+	counts := make(map[string]int)
+	items := make([]map[string]interface{}, len(resources))
+	for i, resource := range resources {
+		if resource.diff != "" && printer.isTable() {
+			fmt.Println(resource.diff)
+		}
+		items[i] = getResultItem(account, resource)
+		items[i]["diff"] = resource.diff
+		counts[resource.status]++
+	}
+
+	if err := printer.print(resultColumns, items); err != nil {
+		ExitWithError(ExitError, fmt.Errorf("Could not print differences\n%s", err))
+	}
+	fmt.Fprintf(printer.messages(), "\n%d to create, %d changed, %d unchanged, %d remote only\n",
+		counts[diffCreated], counts[diffChanged], counts[diffUnchanged], counts[diffRemoteOnly])
+	printRetries()
+
+	exitWithResults(resources)
+
+	exitCode, _ := cmd.PersistentFlags().GetBool("exit-code")
+	if exitCode && counts[diffCreated]+counts[diffChanged]+counts[diffRemoteOnly] > 0 {
+		os.Exit(ExitDrift)
+	}
+}
+
+// diff classifies the resource against the account and compares it with its remote version
+func diff(account string, resource *resource) {
+	classify(account, resource)
+	if resource.err != nil {
+		return
+	}
+
+	local, err := normalize(resource.getType(), resource.bytes)
+	if err != nil {
+		resource.err = fmt.Errorf("invalid local resource: %s", err)
+		return
+	}
+
+	switch resource.action {
+	case actionCreate:
+		resource.status = diffCreated
+		resource.diff = unifiedDiff("/dev/null", resource.path, "", local)
+	case actionUpdate:
+		remote, err := normalizeRemote(resource.getType(), resource.remote, resource.bytes)
+		if err != nil {
+			resource.err = fmt.Errorf("invalid remote resource: %s", err)
+			return
+		}
+		resource.status = diffChanged
+		resource.diff = unifiedDiff(account+"/"+resource.getAPIPath(), resource.path, remote, local)
+	default:
+		resource.status = diffUnchanged
+	}
+}
+
+// normalize converts a resource definition to a canonical text so local and remote
+// versions can be compared regardless of key order, formatting and fields managed by
+// the Outlyer API. Plugins are compared by their decoded source code.
+func normalize(resourceType string, content []byte) (string, error) {
+	definition, err := decodeResource(resourceType, content)
+	if err != nil {
+		return "", err
+	}
+	if resourceType == Plugins {
+		return definition["content"].(string), nil
+	}
+
+	normalized, err := marshalCanonical(resourceType, definition)
+	if err != nil {
+		return "", err
+	}
+	return string(normalized), nil
+}
+
+// normalizeRemote normalizes the remote definition of a resource like normalize, but without the fields
+// that aren't defined locally, since applying the resource keeps them rather than removing them
+func normalizeRemote(resourceType string, remote, local []byte) (string, error) {
+	if resourceType == Plugins {
+		return normalize(resourceType, remote)
+	}
+	remoteDefinition, err := decodeResource(resourceType, remote)
+	if err != nil {
+		return "", err
+	}
+	localDefinition, err := decodeResource(resourceType, local)
+	if err != nil {
+		return "", err
+	}
+
+	definition := withoutRemoteOnlyFields(remoteDefinition, localDefinition).(map[interface{}]interface{})
+	normalized, err := marshalCanonical(resourceType, definition)
+	if err != nil {
+		return "", err
+	}
+	return string(normalized), nil
+}
@@ -0,0 +1,151 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change
+const diffContextLines = 3
+
+type diffLine struct {
+	kind byte // ' ' for unchanged lines, '-' for removed lines and '+' for added lines
+	text string
+}
+
+// unifiedDiff returns the line differences between two texts in the unified format,
+// or an empty string if both texts are equal
+func unifiedDiff(fromName, toName, from, to string) string {
+	lines := diffLines(splitLines(from), splitLines(to))
+
+	// Stores the line number reached on each side before every diff line to build hunk headers
+	fromPos := make([]int, len(lines)+1)
+	toPos := make([]int, len(lines)+1)
+	changed := false
+	for i, line := range lines {
+		fromPos[i+1], toPos[i+1] = fromPos[i], toPos[i]
+		if line.kind != '+' {
+			fromPos[i+1]++
+		}
+		if line.kind != '-' {
+			toPos[i+1]++
+		}
+		if line.kind != ' ' {
+			changed = true
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	for i := 0; i < len(lines); {
+		if lines[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Groups all changes separated by less than twice the context into the same hunk
+		start := i - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContextLines {
+				break
+			}
+		}
+		stop := end + diffContextLines
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+
+		fromCount, toCount := fromPos[stop]-fromPos[start], toPos[stop]-toPos[start]
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", hunkStart(fromPos[start], fromCount), fromCount, hunkStart(toPos[start], toCount), toCount)
+		for _, line := range lines[start:stop] {
+			fmt.Fprintf(&out, "%c%s\n", line.kind, line.text)
+		}
+		i = stop
+	}
+	return out.String()
+}
+
+// diffLines computes the shortest edit script between two lists of lines
+// based on their longest common subsequence
+func diffLines(from, to []string) []diffLine {
+	// Common prefix and suffix are trimmed to keep the LCS table small
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix && from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		suffix++
+	}
+
+	var lines []diffLine
+	for _, text := range from[:prefix] {
+		lines = append(lines, diffLine{' ', text})
+	}
+
+	a, b := from[prefix:len(from)-suffix], to[prefix:len(to)-suffix]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+
+	for _, text := range from[len(from)-suffix:] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	return lines
+}
+
+// hunkStart returns the 1-based line number where a hunk starts, which by
+// convention is the preceding line when the hunk has no lines on that side
+func hunkStart(pos, count int) int {
+	if count == 0 {
+		return pos
+	}
+	return pos + 1
+}
+
+// splitLines splits a text into lines ignoring the trailing new line
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
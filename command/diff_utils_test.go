@@ -0,0 +1,45 @@
+package command
+
+import (
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"trailing new line", "a\nb", "a\nb\n", ""},
+		{"created", "", "a\nb\n", "--- from\n+++ to\n@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"removed", "a\nb\n", "", "--- from\n+++ to\n@@ -1,2 +0,0 @@\n-a\n-b\n"},
+		{"changed", "a\nb\nc\n", "a\nx\nc\n", "--- from\n+++ to\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n"},
+		{
+			"context",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			"1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			"--- from\n+++ to\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			"separate hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			"one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			"--- from\n+++ to\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+		{
+			"merged hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n",
+			"one\n2\n3\n4\n5\n6\n7\neight\n",
+			"--- from\n+++ to\n@@ -1,8 +1,8 @@\n-1\n+one\n 2\n 3\n 4\n 5\n 6\n 7\n-8\n+eight\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := unifiedDiff("from", "to", test.from, test.to); got != test.want {
+				t.Errorf("unifiedDiff() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -3,11 +3,24 @@ package command
 import (
 	"fmt"
 	"os"
+
+	"github.com/outlyerapp/outlyer-cli/api"
 )
 
+// Exit codes returned by the Outlyer CLI, so scripts can tell apart why a command failed
 const (
-	// ExitError represents a general error (http://tldp.org/LDP/abs/html/exitcodes.html)
+	// ExitSuccess means the command succeeded for all resources
+	ExitSuccess = 0
+	// ExitError represents a general error (http://tldp.org/LDP/abs/html/exitcodes.html),
+	// like failing for all resources or being unable to reach the Outlyer API
 	ExitError = 1
+	// ExitPartialFailure means the command succeeded for some resources but failed for others.
+	// The result table lists which resources failed and why.
+	ExitPartialFailure = 2
+	// ExitAuthFailure means the API token is invalid or lacks permissions for the operation
+	ExitAuthFailure = 3
+	// ExitDrift means 'diff --exit-code' found differences between the local resources and the account
+	ExitDrift = 4
 	// ExitBadArgs represents invalid arguments error
 	ExitBadArgs = 128
 )
@@ -15,7 +28,7 @@ const (
 // ExitWithSuccess prints a message to stdout and exits with code 0
 func ExitWithSuccess(msg string) {
 	fmt.Fprintln(os.Stdout, msg)
-	os.Exit(0)
+	os.Exit(ExitSuccess)
 }
 
 // ExitWithError prints an error message to stderr and exits with the specified code
@@ -23,3 +36,42 @@ func ExitWithError(code int, err error) {
 	fmt.Fprintln(os.Stderr, "Error:", err)
 	os.Exit(code)
 }
+
+// getExitCode returns the exit code for an error returned by the Outlyer API
+func getExitCode(err error) int {
+	if api.IsAuthError(err) {
+		return ExitAuthFailure
+	}
+	return ExitError
+}
+
+// exitWithResults exits with an error code if any resource failed, once all resources were processed
+func exitWithResults(resources []resource) {
+	if code, err := getResultsExitCode(resources); code != ExitSuccess {
+		ExitWithError(code, err)
+	}
+}
+
+// getResultsExitCode returns the exit code for the results of processing the resources, along with the error
+// to print if any resource failed: failing because of the API token takes precedence over failing for all
+// resources or only for some of them
+func getResultsExitCode(resources []resource) (int, error) {
+	failed, authFailed := 0, false
+	for _, resource := range resources {
+		if resource.err != nil {
+			failed++
+			authFailed = authFailed || api.IsAuthError(resource.err)
+		}
+	}
+
+	switch {
+	case failed == 0:
+		return ExitSuccess, nil
+	case authFailed:
+		return ExitAuthFailure, fmt.Errorf("%d of %d resources failed, check your API token has permissions for the account", failed, len(resources))
+	case failed == len(resources):
+		return ExitError, fmt.Errorf("all %d resources failed", failed)
+	default:
+		return ExitPartialFailure, fmt.Errorf("%d of %d resources failed", failed, len(resources))
+	}
+}
@@ -0,0 +1,62 @@
+package command
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/outlyerapp/outlyer-cli/api"
+	"github.com/outlyerapp/outlyer-cli/credentials"
+)
+
+func TestGetExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"unauthorized", &api.HTTPError{Code: 401}, ExitAuthFailure},
+		{"forbidden", &api.HTTPError{Code: 403}, ExitAuthFailure},
+		{"no API token", &credentials.Error{Source: "the credential process", Err: fmt.Errorf("exit status 1")}, ExitAuthFailure},
+		{"not found", &api.HTTPError{Code: 404}, ExitError},
+		{"server error", &api.HTTPError{Code: 500}, ExitError},
+		{"unreachable API", fmt.Errorf("connection refused"), ExitError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := getExitCode(test.err); got != test.want {
+				t.Errorf("getExitCode() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetResultsExitCode(t *testing.T) {
+	ok := resource{path: "alerts/docker"}
+	failed := resource{path: "alerts/disk", err: &api.HTTPError{Code: 422, Message: "invalid alert"}}
+	authFailed := resource{path: "alerts/kafka", err: &api.HTTPError{Code: 403}}
+
+	tests := []struct {
+		name      string
+		resources []resource
+		want      int
+		wantErr   string
+	}{
+		{"no resources", nil, ExitSuccess, ""},
+		{"all succeeded", []resource{ok, ok}, ExitSuccess, ""},
+		{"some failed", []resource{ok, failed}, ExitPartialFailure, "1 of 2 resources failed"},
+		{"all failed", []resource{failed, failed}, ExitError, "all 2 resources failed"},
+		{"auth failed", []resource{ok, failed, authFailed}, ExitAuthFailure, "2 of 3 resources failed, check your API token has permissions for the account"},
+		{"all auth failed", []resource{authFailed}, ExitAuthFailure, "1 of 1 resources failed, check your API token has permissions for the account"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := getResultsExitCode(test.resources)
+			if got != test.want {
+				t.Errorf("getResultsExitCode() = %v, want %v", got, test.want)
+			}
+			if (err == nil) != (test.wantErr == "") || (err != nil && err.Error() != test.wantErr) {
+				t.Errorf("getResultsExitCode() error = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
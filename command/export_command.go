@@ -6,14 +6,18 @@ import (
 	"io/ioutil"
 	"os"
 	"os/user"
+	"path"
 	"strings"
-	"sync"
 
 	"github.com/outlyerapp/outlyer-cli/api"
+	"github.com/outlyerapp/outlyer-cli/config"
 	"github.com/spf13/cobra"
 	yaml "gopkg.in/yaml.v2"
 )
 
+// syncIgnoreFile lists the patterns of the files in the export folder that 'export --sync' never deletes
+const syncIgnoreFile = ".outlyerignore"
+
 // NewExportCommand creates a Command for exporting Outlyer resources to disk.
 func NewExportCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -31,19 +35,34 @@ $ outlyer export alerts dashboards --account=<your_account>
 
 Export the account's alerts and only two single dashboards to a specific folder:
 $ outlyer export alerts dashboards/docker dashboards/kafka --account=<your_account> --folder=<your_folder>
+
+Export all dashboards, deleting the local files of dashboards that no longer exist in the account:
+$ outlyer export dashboards --account=<your_account> --sync
+
+List the local files that --sync would delete, without exporting or deleting anything:
+$ outlyer export . --account=<your_account> --sync --dry-run
+
+Export the account's checks exactly as returned by the Outlyer API, including ids and timestamps:
+$ outlyer export checks --account=<your_account> --raw
 `,
 		Run: exportCommand,
 	}
 
-	cmd.PersistentFlags().StringP("account", "a", "", "(Required) User account to use")
+	cmd.PersistentFlags().StringP("account", "a", "", "User account to use. Required unless a default account is set with the 'default-account' configuration or the "+config.AccountEnv+" environment variable")
 	cmd.PersistentFlags().StringP("folder", "f", "", "(Optional) Folder to export resources. If not provided, exports to the current folder")
+	cmd.PersistentFlags().Bool("raw", false, "(Optional) Exports resources exactly as returned by the Outlyer API, without removing the fields it manages like ids and timestamps or ordering fields canonically")
+	cmd.PersistentFlags().Bool("sync", false, "(Optional) Deletes the local files of the exported resource types that no longer match a resource in the account")
+	cmd.PersistentFlags().Bool("dry-run", false, "(Optional) Lists the local files --sync would delete, without exporting or deleting anything")
+	cmd.PersistentFlags().StringArray("ignore", nil, "(Optional) Pattern of local files --sync never deletes, like 'dashboards/wip-*.yaml' or '*.md'. Can be repeated and adds to the patterns of the "+syncIgnoreFile+" file of the export folder")
+	cmd.PersistentFlags().Int("parallelism", 10, "(Optional) Maximum number of concurrent requests to the Outlyer API. Can also be set with the 'parallelism' configuration")
 	return cmd
 }
 
 // exportCommand validates the user input and calls export for each resource
 // provided by the user
 func exportCommand(cmd *cobra.Command, args []string) {
-	account := cmd.PersistentFlags().Lookup("account").Value.String()
+	printer := newPrinter()
+	account := getAccount(cmd)
 	if account == "" {
 		ExitWithError(ExitBadArgs, fmt.Errorf("Account is required"))
 	}
@@ -52,9 +71,12 @@ func exportCommand(cmd *cobra.Command, args []string) {
 	}
 
 	outputFolderFlag := cmd.PersistentFlags().Lookup("folder").Value.String()
-
-	// Creates WaitGroup to wait for goroutines to finish exporting resources concurrently
-	var wg sync.WaitGroup
+	raw, _ := cmd.PersistentFlags().GetBool("raw")
+	syncFolders, _ := cmd.PersistentFlags().GetBool("sync")
+	dryRun, _ := cmd.PersistentFlags().GetBool("dry-run")
+	if dryRun && !syncFolders {
+		ExitWithError(ExitBadArgs, fmt.Errorf("--dry-run can only be used along with --sync"))
+	}
 
 	// Adds all resources if arguments contain "."
 	for _, resourceToFetch := range args {
@@ -71,68 +93,33 @@ func exportCommand(cmd *cobra.Command, args []string) {
 
 	// Fetches resources
 	var resourceNames []string
-	for _, resourceToFetch := range args {
-		var resources []map[string]interface{}
-		if resourceToFetch == Alerts {
-			resp, err := api.Get("/accounts/" + account + "/" + resourceToFetch)
+	namesByType := make(map[string][]string)
+	for _, resourceToFetch := range removeDuplicates(args) {
+		if isResourceType(resourceToFetch) {
+			names, err := listResourceNames(account, resourceToFetch)
 			if err != nil {
-				ExitWithError(ExitError, fmt.Errorf("Could not fetch %s from account %s\n%s", resourceToFetch, account, err))
-			}
-
-			yaml.Unmarshal(resp, &resources)
-
-			for _, resource := range resources {
-				resourceNames = append(resourceNames, "alerts/"+resource["name"].(string))
+				ExitWithError(getExitCode(err), fmt.Errorf("Could not fetch %s from account %s\n%s", resourceToFetch, account, err))
 			}
+			resourceNames = append(resourceNames, names...)
+			namesByType[resourceToFetch] = names
 		}
-		if resourceToFetch == Checks {
-			resp, err := api.Get("/accounts/" + account + "/" + resourceToFetch)
-			if err != nil {
-				ExitWithError(ExitError, fmt.Errorf("Could not fetch %s from account %s\n%s", resourceToFetch, account, err))
-			}
-
-			yaml.Unmarshal(resp, &resources)
-
-			for _, resource := range resources {
-				resourceNames = append(resourceNames, "checks/"+resource["name"].(string))
-			}
-		}
-		if resourceToFetch == Dashboards {
-			resp, err := api.Get("/accounts/" + account + "/" + resourceToFetch)
-			if err != nil {
-				ExitWithError(ExitError, fmt.Errorf("Could not fetch %s from account %s\n%s", resourceToFetch, account, err))
-			}
-
-			yaml.Unmarshal(resp, &resources)
-
-			for _, resource := range resources {
-				resourceNames = append(resourceNames, "dashboards/"+resource["name"].(string))
-			}
-		}
-		if resourceToFetch == Plugins {
-			resp, err := api.Get("/accounts/" + account + "/" + resourceToFetch)
-			if err != nil {
-				ExitWithError(ExitError, fmt.Errorf("Could not fetch %s from account %s\n%s", resourceToFetch, account, err))
-			}
-
-			yaml.Unmarshal(resp, &resources)
+	}
 
-			for _, resource := range resources {
-				resourceNames = append(resourceNames, "plugins/"+resource["name"].(string))
-			}
+	// Only the folders of the resource types exported entirely are synced
+	var staleFiles []staleFile
+	if syncFolders {
+		var err error
+		staleFiles, err = getStaleFiles(outputFolderFlag, namesByType, getIgnorePatterns(cmd, outputFolderFlag))
+		if err != nil {
+			ExitWithError(ExitError, err)
 		}
-		if resourceToFetch == Views {
-			resp, err := api.Get("/accounts/" + account + "/" + resourceToFetch)
-			if err != nil {
-				ExitWithError(ExitError, fmt.Errorf("Could not fetch %s from account %s\n%s", resourceToFetch, account, err))
-			}
-
-			yaml.Unmarshal(resp, &resources)
-
-			for _, resource := range resources {
-				resourceNames = append(resourceNames, "views/"+resource["name"].(string))
-			}
+	}
+	if dryRun {
+		for _, staleFile := range staleFiles {
+			fmt.Fprintln(printer.messages(), "would delete "+staleFile.path)
 		}
+		fmt.Fprintf(printer.messages(), "\nDry run. %d stale files would be deleted, 0 resources exported.\n", len(staleFiles))
+		return
 	}
 	args = remove(args, Alerts)
 	args = remove(args, Dashboards)
@@ -142,64 +129,248 @@ func exportCommand(cmd *cobra.Command, args []string) {
 	args = append(args, resourceNames...)
 	args = removeDuplicates(args)
 
-	// There is no "." argument, so fetches all listed resources
-	for _, resourceToFetch := range args {
-		wg.Add(1)
-		go export(resourceToFetch, account, getOutputFolder(outputFolderFlag, resourceToFetch), &wg)
-	}
-	wg.Wait()
+	// There is no "." argument, so fetches all listed resources. Resources whose files would only differ
+	// in case are not exported, since they would overwrite each other on case-insensitive file systems.
+	collisions := getExportCollisions(args)
+	results := make([]resource, len(args))
+	newWorkerPool(cmd).run(len(args), func(i int) string {
+		results[i] = resource{path: args[i], status: "OK [EXPORTED]"}
+		if others, found := collisions[args[i]]; found {
+			results[i].err = fmt.Errorf("not exported since its file name only differs in case from %s", strings.Join(others, ", "))
+			return "skipped " + args[i]
+		}
+		results[i].err = export(args[i], account, getOutputFolder(outputFolderFlag, args[i]), raw)
+		return "exported " + args[i]
+	})
+	results = append(results, deleteStaleFiles(staleFiles, results)...)
 
-	fmt.Println("Resources successfully exported:")
-	for _, resource := range args {
-		fmt.Printf("- %s\n", resource)
-	}
+	printResults(printer, account, results)
+	exitWithResults(results)
 }
 
-// export queries the resources for the given user account and persists them locally
-func export(resourceToFetch, account, outputFolder string, wg *sync.WaitGroup) {
-	resp, err := api.Get("/accounts/" + account + "/" + resourceToFetch + "?view=export")
+// export queries the resources for the given user account and persists them locally. Unless raw
+// is set, resources are written canonically so exporting unchanged resources leaves files unchanged.
+func export(resourceToFetch, account, outputFolder string, raw bool) error {
+	resp, err := api.Get(accountPath(account, resourceToFetch) + "?view=export")
 	if err != nil {
-		ExitWithError(ExitError, fmt.Errorf("Could not fetch %s from account %s\n%s", resourceToFetch, account, err))
+		return err
 	}
 
-	var resources []map[string]interface{}
+	var resources []map[interface{}]interface{}
 
 	if isSingleResource(resourceToFetch) {
-		var singleResource map[string]interface{}
+		var singleResource map[interface{}]interface{}
 		yaml.Unmarshal(resp, &singleResource)
-		resources = make([]map[string]interface{}, 1)
+		resources = make([]map[interface{}]interface{}, 1)
 		resources[0] = singleResource
 	} else {
 		yaml.Unmarshal(resp, &resources)
 	}
 
 	os.MkdirAll(outputFolder, 0755)
+	resourceType, _ := splitAPIPath(resourceToFetch)
 
 	for _, resource := range resources {
 		var resourceInBytes []byte
-		var resourceFileName string
-		resourceName := resource["name"].(string)
+		resourceName, ok := resource["name"].(string)
+		if !ok {
+			return fmt.Errorf("resource has no name")
+		}
 
-		if strings.Contains(resourceToFetch, "plugins") {
-			resourceInBytes, err = base64.StdEncoding.DecodeString(resource["content"].(string))
+		if resourceType == Plugins {
+			content, _ := resource["content"].(string)
+			resourceInBytes, err = base64.StdEncoding.DecodeString(content)
 			if err != nil {
-				ExitWithError(ExitError, fmt.Errorf("Could not decode plugin %s\n%s", resourceName, err))
+				return fmt.Errorf("could not decode plugin %s: %s", resourceName, err)
 			}
-			resourceFileName = outputFolder + resourceName
 		} else {
-			resourceInBytes, err = yaml.Marshal(&resource)
+			if raw {
+				resourceInBytes, err = yaml.Marshal(&resource)
+			} else {
+				resourceInBytes, err = marshalCanonical(resourceType, resource)
+			}
 			if err != nil {
-				ExitWithError(ExitError, fmt.Errorf("Error marshalling resource %s\n%s", resourceName, err))
+				return fmt.Errorf("error marshalling resource %s: %s", resourceName, err)
 			}
-			resourceFileName = outputFolder + resourceName + ".yaml"
 		}
 
+		// Names are mapped to safe file names, so resources can't be written outside the output folder
+		resourceFileName := outputFolder + getResourceFileName(resourceType, resourceName)
+
 		err := ioutil.WriteFile(resourceFileName, resourceInBytes, 0644)
 		if err != nil {
-			ExitWithError(ExitError, fmt.Errorf("Could not write resource %s to disk\n%s", resourceFileName, err))
+			return fmt.Errorf("could not write resource %s to disk: %s", resourceFileName, err)
+		}
+	}
+	return nil
+}
+
+// getExportCollisions finds the resources to export, like 'dashboards/CPU', whose files would only differ
+// in case from the files of other resources, and maps each of them to the files it collides with
+func getExportCollisions(apiPaths []string) map[string][]string {
+	apiPathByFile := make(map[string]string)
+	var files []string
+	for _, apiPath := range apiPaths {
+		resourceType, name := splitAPIPath(apiPath)
+		file := resourceType + "/" + getResourceFileName(resourceType, name)
+		apiPathByFile[file] = apiPath
+		files = append(files, file)
+	}
+
+	collisions := make(map[string][]string)
+	for _, group := range findCaseCollisions(files) {
+		for _, file := range group {
+			for _, other := range group {
+				if other != file {
+					collisions[apiPathByFile[file]] = append(collisions[apiPathByFile[file]], other)
+				}
+			}
 		}
 	}
-	wg.Done()
+	return collisions
+}
+
+// staleFile is a local file that doesn't match any resource of its type in the account. The type is
+// kept along with the path, since the export folder may have any name, even that of a resource type.
+type staleFile struct {
+	path         string
+	resourceType string
+}
+
+// getStaleFiles lists the local files in the folders of the given resource types that don't match any
+// of the resource names, like 'dashboards/docker'. Only YAML files and plugins are considered, and
+// hidden files and those matching any of the ignore patterns are never stale.
+func getStaleFiles(outputFolderFlag string, namesByType map[string][]string, ignorePatterns []string) ([]staleFile, error) {
+	var staleFiles []staleFile
+	for _, resourceType := range resourceTypes {
+		typeNames, exported := namesByType[resourceType]
+		if !exported {
+			continue
+		}
+		names := make(map[string]bool)
+		for _, name := range typeNames {
+			names[name] = true
+		}
+
+		folder := getOutputFolder(outputFolderFlag, resourceType)
+		files, err := ioutil.ReadDir(folder)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Could not read %s\n%s", folder, err)
+		}
+		for _, file := range files {
+			fileName := file.Name()
+			if file.IsDir() || strings.HasPrefix(fileName, ".") || isIgnored(resourceType+"/"+fileName, ignorePatterns) {
+				continue
+			}
+			name := fileName
+			if resourceType != Plugins {
+				extension := path.Ext(fileName)
+				if extension != ".yaml" && extension != ".yml" {
+					continue
+				}
+				name = strings.TrimSuffix(fileName, extension)
+			}
+			if !names[resourceType+"/"+fileNameToName(name)] {
+				staleFiles = append(staleFiles, staleFile{path: folder + fileName, resourceType: resourceType})
+			}
+		}
+	}
+	return staleFiles, nil
+}
+
+// deleteStaleFiles deletes the stale files, except those of resource types that failed to be exported,
+// since their files may still match resources that are left to export
+func deleteStaleFiles(staleFiles []staleFile, exported []resource) []resource {
+	failedTypes := make(map[string]bool)
+	for _, res := range exported {
+		if res.err != nil {
+			resourceType, _ := splitAPIPath(res.path)
+			failedTypes[resourceType] = true
+		}
+	}
+
+	var results []resource
+	for _, staleFile := range staleFiles {
+		res := resource{path: staleFile.path, status: "OK [DELETED]"}
+		if failedTypes[staleFile.resourceType] {
+			res.status = statusSkipped
+			res.err = fmt.Errorf("not deleted since some %s could not be exported", staleFile.resourceType)
+		} else if err := os.Remove(staleFile.path); err != nil {
+			res.err = fmt.Errorf("could not delete stale file: %s", err)
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// getIgnorePatterns returns the patterns of the files never deleted by --sync, both set with --ignore and
+// read from the ignore file of the export folder, which has one pattern per line and comments starting with #
+func getIgnorePatterns(cmd *cobra.Command, outputFolderFlag string) []string {
+	patterns, _ := cmd.PersistentFlags().GetStringArray("ignore")
+	ignoreFile := strings.TrimSuffix(getOutputFolder(outputFolderFlag, Alerts), Alerts+"/") + syncIgnoreFile
+	content, err := ioutil.ReadFile(ignoreFile)
+	if err != nil && !os.IsNotExist(err) {
+		ExitWithError(ExitError, fmt.Errorf("Could not read %s\n%s", ignoreFile, err))
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			ExitWithError(ExitBadArgs, fmt.Errorf("invalid ignore pattern '%s'", pattern))
+		}
+	}
+	return patterns
+}
+
+// isIgnored checks whether the file, like 'dashboards/docker.yaml', matches any of the ignore patterns.
+// Like in .gitignore files, patterns without a slash match the file name in any folder.
+func isIgnored(file string, patterns []string) bool {
+	for _, pattern := range patterns {
+		target := file
+		if !strings.Contains(pattern, "/") {
+			target = path.Base(file)
+		}
+		if matched, _ := path.Match(pattern, target); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// listResources fetches all resources of the given type from the user account
+func listResources(account, resourceType string) ([]map[string]interface{}, error) {
+	resp, err := api.Get(accountPath(account, resourceType))
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []map[string]interface{}
+	if err := yaml.Unmarshal(resp, &resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// listResourceNames fetches all resources of the given type from the user account
+// and returns their names prefixed by the resource type, like 'dashboards/docker'
+func listResourceNames(account, resourceType string) ([]string, error) {
+	resources, err := listResources(account, resourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	var resourceNames []string
+	for _, resource := range resources {
+		resourceNames = append(resourceNames, resourceType+"/"+fmt.Sprint(resource["name"]))
+	}
+	return resourceNames, nil
 }
 
 // getOutputFolder is a helper function to build the correct output folder to export the given resource
@@ -2,7 +2,11 @@ package command
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"os/user"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -52,3 +56,109 @@ func TestGetOutputFolder(t *testing.T) {
 		})
 	}
 }
+
+func TestGetStaleFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, file := range []string{
+		"alerts/docker.yaml", "alerts/old.yaml", "alerts/CPU%20Load.yaml", "alerts/README.md", "alerts/.gitkeep", "alerts/archive/old.yaml",
+		"dashboards/docker.yml", "dashboards/wip-kafka.yaml",
+		"plugins/docker.py", "plugins/old.sh",
+		"checks/old.yaml",
+	} {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, file)), 0755)
+		if err := ioutil.WriteFile(filepath.Join(dir, file), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	namesByType := map[string][]string{
+		Alerts:     {"alerts/docker", "alerts/CPU Load"},
+		Dashboards: nil,
+		Plugins:    {"plugins/docker.py"},
+		Views:      {"views/hosts"},
+	}
+	got, err := getStaleFiles(dir, namesByType, []string{"dashboards/wip-*"})
+	if err != nil {
+		t.Fatalf("getStaleFiles() error = %v", err)
+	}
+	want := []staleFile{
+		{path: dir + "/alerts/old.yaml", resourceType: Alerts},
+		{path: dir + "/dashboards/docker.yml", resourceType: Dashboards},
+		{path: dir + "/plugins/old.sh", resourceType: Plugins},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getStaleFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestDeleteStaleFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The export folder is named like a resource type, so only the types of the stale files tell them apart
+	folder := filepath.Join(dir, "alerts")
+	staleFiles := []staleFile{
+		{path: filepath.Join(folder, "alerts", "old.yaml"), resourceType: Alerts},
+		{path: filepath.Join(folder, "checks", "old.yaml"), resourceType: Checks},
+	}
+	for _, staleFile := range staleFiles {
+		os.MkdirAll(filepath.Dir(staleFile.path), 0755)
+		if err := ioutil.WriteFile(staleFile.path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exported := []resource{
+		{path: "alerts/docker", err: fmt.Errorf("could not fetch")},
+		{path: "checks/docker"},
+	}
+
+	results := deleteStaleFiles(staleFiles, exported)
+	if len(results) != 2 || results[0].status != statusSkipped || results[1].status != "OK [DELETED]" || results[1].err != nil {
+		t.Fatalf("deleteStaleFiles() = %v, want the alert skipped and the check deleted", results)
+	}
+	if !fileOrDirExists(staleFiles[0].path) || fileOrDirExists(staleFiles[1].path) {
+		t.Errorf("deleteStaleFiles() deleted the wrong files")
+	}
+}
+
+func TestIsIgnored(t *testing.T) {
+	tests := []struct {
+		file     string
+		patterns []string
+		want     bool
+	}{
+		{"dashboards/wip-kafka.yaml", []string{"dashboards/wip-*"}, true},
+		{"alerts/wip-kafka.yaml", []string{"dashboards/wip-*"}, false},
+		{"alerts/wip-kafka.yaml", []string{"wip-*"}, true},
+		{"plugins/docker.py", []string{"*.sh", "plugins/*.py"}, true},
+		{"plugins/docker.py", nil, false},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s,%v", test.file, test.patterns), func(t *testing.T) {
+			if got := isIgnored(test.file, test.patterns); got != test.want {
+				t.Errorf("isIgnored() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetExportCollisions(t *testing.T) {
+	got := getExportCollisions([]string{"dashboards/CPU", "dashboards/cpu", "dashboards/disk", "alerts/cpu", "plugins/run.sh", "plugins/RUN.sh"})
+	want := map[string][]string{
+		"dashboards/CPU": {"dashboards/cpu.yaml"},
+		"dashboards/cpu": {"dashboards/CPU.yaml"},
+		"plugins/RUN.sh": {"plugins/run.sh"},
+		"plugins/run.sh": {"plugins/RUN.sh"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getExportCollisions() = %v, want %v", got, want)
+	}
+}
@@ -5,8 +5,15 @@ import (
 
 	"github.com/outlyerapp/outlyer-cli/api"
 	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
 )
 
+// accountColumns are the columns of the table listing the user's accounts
+var accountColumns = []column{
+	{header: "NAME", fields: []string{"name"}},
+	{header: "TITLE", fields: []string{"title"}},
+}
+
 // NewGetAccountCommand creates a Command to list the user's accounts
 func NewGetAccountCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -19,9 +26,17 @@ func NewGetAccountCommand() *cobra.Command {
 
 // listUserAccounts fetches the Outlyer API and lists the user's accounts
 func listUserAccounts(cmd *cobra.Command, args []string) {
+	printer := newPrinter()
 	resp, err := api.Get("/accounts")
 	if err != nil {
-		ExitWithError(ExitError, fmt.Errorf("Could not fetch user's accounts\n%s", err))
+		ExitWithError(getExitCode(err), fmt.Errorf("Could not fetch user's accounts\n%s", err))
+	}
+
+	var accounts []map[string]interface{}
+	if err := yaml.Unmarshal(resp, &accounts); err != nil {
+		ExitWithError(ExitError, fmt.Errorf("Could not read user's accounts\n%s", err))
+	}
+	if err := printer.print(accountColumns, accounts); err != nil {
+		ExitWithError(ExitError, fmt.Errorf("Could not print user's accounts\n%s", err))
 	}
-	ExitWithSuccess(string(resp))
 }
@@ -2,12 +2,15 @@ package command
 
 import "github.com/spf13/cobra"
 
-// NewGetCommand groups subcommands like "accounts"
+// NewGetCommand groups subcommands like "accounts" and "alerts"
 func NewGetCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "get",
-		Short: "Get the specified resources. The available resources are: 'accounts'",
+		Short: "Get the specified resources. The available resources are: accounts, alerts, checks, dashboards, plugins and views",
 	}
 	cmd.AddCommand(NewGetAccountCommand())
+	for _, resourceType := range resourceTypes {
+		cmd.AddCommand(NewGetResourcesCommand(resourceType))
+	}
 	return cmd
 }
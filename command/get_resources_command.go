@@ -0,0 +1,258 @@
+package command
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/outlyerapp/outlyer-cli/config"
+	"github.com/spf13/cobra"
+)
+
+// Fields that resources may have to tell when they were last modified, whether they are enabled and who owns them
+var (
+	modifiedFields = []string{"updated_at", "modified_at", "last_modified", "updated"}
+	enabledFields  = []string{"enabled", "active"}
+	statusFields   = []string{"status", "state"}
+	ownerFields    = []string{"owner", "created_by", "author"}
+)
+
+// resourceColumns are the columns of the table listing each resource type
+var resourceColumns = map[string][]column{
+	Alerts: {
+		{header: "NAME", fields: []string{"name"}},
+		{header: "ENABLED", fields: enabledFields},
+		{header: "STATUS", fields: statusFields},
+		{header: "OWNER", fields: ownerFields},
+		{header: "LAST MODIFIED", fields: modifiedFields},
+	},
+	Checks: {
+		{header: "NAME", fields: []string{"name"}},
+		{header: "ENABLED", fields: enabledFields},
+		{header: "INTERVAL", fields: []string{"interval"}},
+		{header: "LAST MODIFIED", fields: modifiedFields},
+	},
+	Dashboards: {
+		{header: "NAME", fields: []string{"name"}},
+		{header: "TITLE", fields: []string{"title"}},
+		{header: "OWNER", fields: ownerFields},
+		{header: "LAST MODIFIED", fields: modifiedFields},
+	},
+	Plugins: {
+		{header: "NAME", fields: []string{"name"}},
+		{header: "OWNER", fields: ownerFields},
+		{header: "LAST MODIFIED", fields: modifiedFields},
+	},
+	Views: {
+		{header: "NAME", fields: []string{"name"}},
+		{header: "TITLE", fields: []string{"title"}},
+		{header: "OWNER", fields: ownerFields},
+		{header: "LAST MODIFIED", fields: modifiedFields},
+	},
+}
+
+// NewGetResourcesCommand creates a Command to list the resources of the given type from the user's account
+func NewGetResourcesCommand(resourceType string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   resourceType + " [name]...",
+		Short: "List " + resourceType + " from the specified account",
+		Example: fmt.Sprintf(`
+List all %[1]s from the account:
+$ outlyer get %[1]s --account=<your_account>
+
+List the %[1]s whose name starts with 'docker', most recently modified first:
+$ outlyer get %[1]s --account=<your_account> --name='docker*' --sort-by=last-modified --reverse
+
+Print the names of all %[1]s as JSON:
+$ outlyer get %[1]s --account=<your_account> -o json`, resourceType),
+		Run: func(cmd *cobra.Command, args []string) {
+			getResourcesCommand(cmd, resourceType, args)
+		},
+	}
+
+	cmd.PersistentFlags().StringP("account", "a", "", "User account to use. Required unless a default account is set with the 'default-account' configuration or the "+config.AccountEnv+" environment variable")
+	cmd.PersistentFlags().String("name", "", "(Optional) Lists only the resources whose name matches the glob pattern, like 'docker*'")
+	cmd.PersistentFlags().String("sort-by", "name", "(Optional) Column to sort by: "+strings.Join(getSortKeys(resourceColumns[resourceType]), ", "))
+	cmd.PersistentFlags().Bool("reverse", false, "(Optional) Sorts in descending order")
+	return cmd
+}
+
+// getResourcesCommand lists the resources of the given type, filtered by name and sorted by the given column
+func getResourcesCommand(cmd *cobra.Command, resourceType string, args []string) {
+	printer := newPrinter()
+	account := getAccount(cmd)
+	if account == "" {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Account is required"))
+	}
+
+	columns := resourceColumns[resourceType]
+	sortBy := cmd.PersistentFlags().Lookup("sort-by").Value.String()
+	sortColumn, found := getSortColumn(columns, sortBy)
+	if !found {
+		ExitWithError(ExitBadArgs, fmt.Errorf("%s: unknown column to sort by. The available columns are: %s", sortBy, strings.Join(getSortKeys(columns), ", ")))
+	}
+
+	pattern := cmd.PersistentFlags().Lookup("name").Value.String()
+	if _, err := path.Match(pattern, ""); err != nil {
+		ExitWithError(ExitBadArgs, fmt.Errorf("%s: invalid name pattern", pattern))
+	}
+
+	resources, err := listResources(account, resourceType)
+	if err != nil {
+		ExitWithError(getExitCode(err), fmt.Errorf("Could not fetch %s from account %s\n%s", resourceType, account, err))
+	}
+
+	resources, missing := filterResources(resources, args, pattern)
+	if len(missing) > 0 {
+		ExitWithError(ExitError, fmt.Errorf("%s/%s: not found in account %s", resourceType, strings.Join(missing, ", "+resourceType+"/"), account))
+	}
+
+	reverse, _ := cmd.PersistentFlags().GetBool("reverse")
+	sortResources(resources, sortColumn, reverse)
+
+	if err := printer.print(columns, resources); err != nil {
+		ExitWithError(ExitError, fmt.Errorf("Could not print %s\n%s", resourceType, err))
+	}
+}
+
+// filterResources returns the resources with any of the given names, or all of them if no names are given,
+// whose name matches the glob pattern. It also returns the given names no resource has.
+func filterResources(resources []map[string]interface{}, names []string, pattern string) ([]map[string]interface{}, []string) {
+	isRequested := make(map[string]bool)
+	for _, name := range names {
+		isRequested[name] = true
+	}
+
+	found := make(map[string]bool)
+	var filtered []map[string]interface{}
+	for _, resource := range resources {
+		name := fmt.Sprint(resource["name"])
+		if len(names) > 0 && !isRequested[name] {
+			continue
+		}
+		found[name] = true
+		if matched, _ := path.Match(pattern, name); pattern != "" && !matched {
+			continue
+		}
+		filtered = append(filtered, resource)
+	}
+
+	var missing []string
+	for _, name := range names {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	return filtered, missing
+}
+
+// sortResources sorts the resources by the value of the column, and by name when they have the same value
+func sortResources(resources []map[string]interface{}, sortColumn column, reverse bool) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		comparison := compareSortValues(sortColumn.getRawValue(resources[i]), sortColumn.getRawValue(resources[j]))
+		if comparison == 0 {
+			comparison = strings.Compare(fmt.Sprint(resources[i]["name"]), fmt.Sprint(resources[j]["name"]))
+		}
+		if reverse {
+			return comparison > 0
+		}
+		return comparison < 0
+	})
+}
+
+// sortTimeLayouts are the layouts of the times resources are sorted by, like the time they were last modified
+var sortTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05", "2006-01-02"}
+
+// compareSortValues compares two column values, returning a negative number if the first one sorts first,
+// a positive one if the second one does and 0 if they are equal. Numbers and times are compared by their
+// value, so 9 sorts before 10, and other values as text. Missing values sort first.
+func compareSortValues(first, second interface{}) int {
+	switch {
+	case first == nil && second == nil:
+		return 0
+	case first == nil:
+		return -1
+	case second == nil:
+		return 1
+	}
+
+	firstNumber, firstIsNumber := toSortNumber(first)
+	secondNumber, secondIsNumber := toSortNumber(second)
+	if firstIsNumber && secondIsNumber {
+		return compareFloats(firstNumber, secondNumber)
+	}
+
+	firstTime, firstIsTime := toSortTime(first)
+	secondTime, secondIsTime := toSortTime(second)
+	if firstIsTime && secondIsTime {
+		return compareFloats(float64(firstTime.UnixNano()), float64(secondTime.UnixNano()))
+	}
+	return strings.Compare(fmt.Sprint(first), fmt.Sprint(second))
+}
+
+// toSortNumber returns the value as a number if it is one
+func toSortNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// toSortTime returns the value as a time if it is one or a text in one of the sortTimeLayouts
+func toSortTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range sortTimeLayouts {
+			if parsed, err := time.Parse(layout, v); err == nil {
+				return parsed, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// compareFloats compares two numbers like compareSortValues
+func compareFloats(first, second float64) int {
+	switch {
+	case first < second:
+		return -1
+	case first > second:
+		return 1
+	}
+	return 0
+}
+
+// getSortColumn returns the column with the given sort key
+func getSortColumn(columns []column, sortKey string) (column, bool) {
+	for _, column := range columns {
+		if getSortKey(column) == strings.ToLower(sortKey) {
+			return column, true
+		}
+	}
+	return column{}, false
+}
+
+// getSortKeys returns the keys to sort by each of the columns
+func getSortKeys(columns []column) []string {
+	keys := make([]string, len(columns))
+	for i, column := range columns {
+		keys[i] = getSortKey(column)
+	}
+	return keys
+}
+
+// getSortKey returns the key to sort by the column, like 'last-modified' for the 'LAST MODIFIED' column
+func getSortKey(column column) string {
+	return strings.ToLower(strings.Replace(column.header, " ", "-", -1))
+}
@@ -0,0 +1,98 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterResources(t *testing.T) {
+	resources := []map[string]interface{}{{"name": "docker"}, {"name": "docker-swarm"}, {"name": "kafka"}}
+
+	tests := []struct {
+		name        string
+		names       []string
+		pattern     string
+		want        []string
+		wantMissing []string
+	}{
+		{"all", nil, "", []string{"docker", "docker-swarm", "kafka"}, nil},
+		{"glob", nil, "docker*", []string{"docker", "docker-swarm"}, nil},
+		{"names", []string{"kafka", "docker"}, "", []string{"docker", "kafka"}, nil},
+		{"names and glob", []string{"kafka", "docker"}, "d*", []string{"docker"}, nil},
+		{"missing names", []string{"kafka", "redis"}, "", []string{"kafka"}, []string{"redis"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filtered, missing := filterResources(resources, test.names, test.pattern)
+			var got []string
+			for _, resource := range filtered {
+				got = append(got, resource["name"].(string))
+			}
+			if !reflect.DeepEqual(got, test.want) || !reflect.DeepEqual(missing, test.wantMissing) {
+				t.Errorf("filterResources() = %v, %v, want %v, %v", got, missing, test.want, test.wantMissing)
+			}
+		})
+	}
+}
+
+func TestSortResourcesByValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		sortKey   string
+		resources []map[string]interface{}
+		want      []string
+	}{
+		{"numbers", "interval", []map[string]interface{}{
+			{"name": "kafka", "interval": 10},
+			{"name": "docker", "interval": 9},
+			{"name": "redis", "interval": 60.5},
+			{"name": "disk"},
+		}, []string{"disk", "docker", "kafka", "redis"}},
+		{"times", "last-modified", []map[string]interface{}{
+			{"name": "kafka", "updated_at": "2018-05-01T12:00:00+02:00"},
+			{"name": "docker", "updated_at": "2018-05-01T11:00:00Z"},
+			{"name": "redis", "updated_at": "2018-04-30"},
+			{"name": "disk", "created_at": "2018-06-01"},
+		}, []string{"disk", "redis", "kafka", "docker"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sortColumn, _ := getSortColumn(resourceColumns[Checks], test.sortKey)
+			sortResources(test.resources, sortColumn, false)
+			var got []string
+			for _, resource := range test.resources {
+				got = append(got, resource["name"].(string))
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("sortResources() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSortResources(t *testing.T) {
+	sortColumn, _ := getSortColumn(resourceColumns[Alerts], "LAST-MODIFIED")
+	resources := []map[string]interface{}{
+		{"name": "kafka", "updated_at": "2018-03-01"},
+		{"name": "docker", "updated_at": "2018-05-01"},
+		{"name": "redis", "modified_at": "2018-03-01"},
+	}
+
+	tests := []struct {
+		reverse bool
+		want    []string
+	}{
+		{false, []string{"kafka", "redis", "docker"}},
+		{true, []string{"docker", "redis", "kafka"}},
+	}
+	for _, test := range tests {
+		sortResources(resources, sortColumn, test.reverse)
+		var got []string
+		for _, resource := range resources {
+			got = append(got, resource["name"].(string))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("sortResources(reverse=%v) = %v, want %v", test.reverse, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package command
+
+import (
+	"sort"
+)
+
+// typeRanks orders resource types so resources are applied after the types they usually depend on:
+// plugins before checks before alerts, and views before dashboards
+var typeRanks = map[string]int{
+	Plugins:    0,
+	Views:      0,
+	Checks:     1,
+	Dashboards: 1,
+	Alerts:     2,
+}
+
+// getApplyWaves groups the resources in waves that must be applied one after the other, so every
+// resource is applied after the resources of the types it depends on and the resources it references
+// explicitly in its definition. Resources to delete go last and in reverse order, so they are deleted
+// before the resources they depend on. It also returns, for each resource, the index of the resources
+// it references so they can be skipped if any of them fails.
+func getApplyWaves(resources []resource) ([][]int, map[int][]int) {
+	indexByName := make(map[string]int)
+	for i, resource := range resources {
+		if resource.action != actionDelete {
+			indexByName[resource.getAPIPath()] = i
+		}
+	}
+
+	dependencies := make(map[int][]int)
+	for i, resource := range resources {
+		if resource.action == actionDelete || resource.err != nil {
+			continue
+		}
+		definition, err := decodeResource(resource.getType(), resource.bytes)
+		if err != nil {
+			continue
+		}
+		for _, reference := range extractReferences(definition) {
+			if j, found := indexByName[reference]; found && j != i {
+				dependencies[i] = append(dependencies[i], j)
+			}
+		}
+	}
+
+	levels := make(map[int]int)
+	visiting := make(map[int]bool)
+	var levelOf func(i int) int
+	levelOf = func(i int) int {
+		if level, found := levels[i]; found {
+			return level
+		}
+		visiting[i] = true
+		level := typeRanks[resources[i].getType()]
+		for _, j := range dependencies[i] {
+			if visiting[j] { // Ignores circular references, which can't be ordered
+				continue
+			}
+			if dependencyLevel := levelOf(j) + 1; dependencyLevel > level {
+				level = dependencyLevel
+			}
+		}
+		visiting[i] = false
+		levels[i] = level
+		return level
+	}
+
+	maxLevel := 0
+	for i, resource := range resources {
+		if resource.action != actionDelete {
+			if level := levelOf(i); level > maxLevel {
+				maxLevel = level
+			}
+		}
+	}
+	maxRank := typeRanks[Alerts]
+	for i, resource := range resources {
+		if resource.action == actionDelete {
+			levels[i] = maxLevel + 1 + maxRank - typeRanks[resource.getType()]
+		}
+	}
+
+	wavesByLevel := make(map[int][]int)
+	var sortedLevels []int
+	for i := range resources {
+		level := levels[i]
+		if _, found := wavesByLevel[level]; !found {
+			sortedLevels = append(sortedLevels, level)
+		}
+		wavesByLevel[level] = append(wavesByLevel[level], i)
+	}
+	sort.Ints(sortedLevels)
+
+	waves := make([][]int, 0, len(sortedLevels))
+	for _, level := range sortedLevels {
+		waves = append(waves, wavesByLevel[level])
+	}
+	return waves, dependencies
+}
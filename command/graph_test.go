@@ -0,0 +1,46 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetApplyWaves(t *testing.T) {
+	resources := []resource{
+		{path: "demo/alerts/docker.yaml", bytes: []byte("name: docker\ncriteria:\n- check: docker")},
+		{path: "demo/checks/docker.yaml", bytes: []byte("name: docker\ncommand: docker.py")},
+		{path: "demo/dashboards/docker.yaml", bytes: []byte("name: docker\nwidgets:\n- alert: docker")},
+		{path: "demo/plugins/docker.py", bytes: []byte("content: cHJpbnQ=\nencoding: base64\nname: docker.py")},
+		{path: "demo/views/docker.yaml", bytes: []byte("name: docker")},
+		{path: "plugins/old.py", action: actionDelete},
+		{path: "alerts/old", action: actionDelete},
+	}
+
+	waves, dependencies := getApplyWaves(resources)
+
+	wantWaves := [][]int{{3, 4}, {1}, {0}, {2}, {6}, {5}}
+	if !reflect.DeepEqual(waves, wantWaves) {
+		t.Errorf("getApplyWaves() waves = %v, want %v", waves, wantWaves)
+	}
+	wantDependencies := map[int][]int{0: {1}, 1: {3}, 2: {0}}
+	if !reflect.DeepEqual(dependencies, wantDependencies) {
+		t.Errorf("getApplyWaves() dependencies = %v, want %v", dependencies, wantDependencies)
+	}
+}
+
+func TestGetApplyWavesWithCircularReferences(t *testing.T) {
+	resources := []resource{
+		{path: "demo/alerts/a.yaml", bytes: []byte("name: a\nalert: b")},
+		{path: "demo/alerts/b.yaml", bytes: []byte("name: b\nalert: a")},
+	}
+
+	waves, _ := getApplyWaves(resources)
+
+	count := 0
+	for _, wave := range waves {
+		count += len(wave)
+	}
+	if count != len(resources) {
+		t.Errorf("getApplyWaves() waves = %v, want all %d resources", waves, len(resources))
+	}
+}
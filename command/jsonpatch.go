@@ -0,0 +1,231 @@
+package command
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// jsonPatchOperation is a single operation of a JSON patch (RFC 6902), like
+// '{op: replace, path: /criteria/0/threshold, value: 95}'
+type jsonPatchOperation struct {
+	Op    string      `yaml:"op"`
+	Path  string      `yaml:"path"`
+	From  string      `yaml:"from"`
+	Value interface{} `yaml:"value"`
+}
+
+// applyJSONPatch applies the operations in order to the document decoded from YAML, returning the patched document
+func applyJSONPatch(document interface{}, operations []jsonPatchOperation) (interface{}, error) {
+	for i, operation := range operations {
+		patched, err := applyJSONPatchOperation(document, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %s", i+1, operation.Op, operation.Path, err)
+		}
+		document = patched
+	}
+	return document, nil
+}
+
+func applyJSONPatchOperation(document interface{}, operation jsonPatchOperation) (interface{}, error) {
+	tokens, err := parseJSONPointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add":
+		return jsonPointerSet(document, tokens, copyValue(operation.Value), false)
+	case "replace":
+		return jsonPointerSet(document, tokens, copyValue(operation.Value), true)
+	case "remove":
+		return jsonPointerRemove(document, tokens)
+	case "test":
+		value, err := jsonPointerGet(document, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(toJSONValue(value), toJSONValue(operation.Value)) {
+			return nil, fmt.Errorf("value is %s, not %s", formatValue(value), formatValue(operation.Value))
+		}
+		return document, nil
+	case "move", "copy":
+		fromTokens, err := parseJSONPointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := jsonPointerGet(document, fromTokens)
+		if err != nil {
+			return nil, fmt.Errorf("from %s: %s", operation.From, err)
+		}
+		if operation.Op == "move" {
+			if document, err = jsonPointerRemove(document, fromTokens); err != nil {
+				return nil, err
+			}
+		} else {
+			value = copyValue(value)
+		}
+		return jsonPointerSet(document, tokens, value, false)
+	}
+	return nil, fmt.Errorf("unknown operation, it must be one of add, remove, replace, move, copy and test")
+}
+
+// parseJSONPointer splits a JSON pointer (RFC 6901) like '/criteria/0/threshold' into its reference tokens
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%s: paths must start with '/'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// jsonPointerGet returns the value the tokens point to
+func jsonPointerGet(document interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch node := document.(type) {
+		case map[interface{}]interface{}:
+			value, found := node[token]
+			if !found {
+				return nil, fmt.Errorf("%s: not found", token)
+			}
+			document = value
+		case []interface{}:
+			i, err := getArrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			document = node[i]
+		default:
+			return nil, fmt.Errorf("%s: not found, the parent is not an object or an array", token)
+		}
+	}
+	return document, nil
+}
+
+// jsonPointerSet sets the value the tokens point to. Adding inserts it into arrays, where '-' appends
+// it, while replacing requires the value to exist.
+func jsonPointerSet(document interface{}, tokens []string, value interface{}, replace bool) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	token, last := tokens[0], len(tokens) == 1
+
+	switch node := document.(type) {
+	case map[interface{}]interface{}:
+		child, found := node[token]
+		if !found && (replace || !last) {
+			return nil, fmt.Errorf("%s: not found", token)
+		}
+		if last {
+			node[token] = value
+			return node, nil
+		}
+		updated, err := jsonPointerSet(child, tokens[1:], value, replace)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = updated
+		return node, nil
+	case []interface{}:
+		if last && !replace {
+			i := len(node)
+			if token != "-" {
+				var err error
+				if i, err = getArrayIndex(token, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		}
+		i, err := getArrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		if last {
+			node[i] = value
+			return node, nil
+		}
+		if node[i], err = jsonPointerSet(node[i], tokens[1:], value, replace); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+	return nil, fmt.Errorf("%s: not found, the parent is not an object or an array", token)
+}
+
+// jsonPointerRemove removes the value the tokens point to
+func jsonPointerRemove(document interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("the whole resource can't be removed, use 'remove' in the overlay instead")
+	}
+	token, last := tokens[0], len(tokens) == 1
+
+	switch node := document.(type) {
+	case map[interface{}]interface{}:
+		child, found := node[token]
+		if !found {
+			return nil, fmt.Errorf("%s: not found", token)
+		}
+		if last {
+			delete(node, token)
+			return node, nil
+		}
+		updated, err := jsonPointerRemove(child, tokens[1:])
+		if err != nil {
+			return nil, err
+		}
+		node[token] = updated
+		return node, nil
+	case []interface{}:
+		i, err := getArrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		if last {
+			return append(node[:i], node[i+1:]...), nil
+		}
+		if node[i], err = jsonPointerRemove(node[i], tokens[1:]); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+	return nil, fmt.Errorf("%s: not found, the parent is not an object or an array", token)
+}
+
+// getArrayIndex parses an array index, which must be between 0 and max
+func getArrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("%s: invalid array index", token)
+	}
+	return i, nil
+}
+
+// copyValue returns a deep copy of a value decoded from YAML, so patching a value doesn't change the others sharing it
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		copied := make(map[interface{}]interface{}, len(v))
+		for key, fieldValue := range v {
+			copied[key] = copyValue(fieldValue)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyValue(item)
+		}
+		return copied
+	}
+	return value
+}
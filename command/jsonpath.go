@@ -0,0 +1,206 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a template with JSONPath expressions between braces, like '{[*].name}' or
+// 'name: {[0].name}{"\n"}'. It supports the subset of JSONPath needed to pick fields from
+// the printed items: child fields ('.name' or "['name']"), indexes ('[0]', '[-1]') and wildcards ('[*]', '.*').
+type jsonPath struct {
+	segments []jsonPathSegment
+}
+
+// jsonPathSegment is either literal text or an expression to evaluate
+type jsonPathSegment struct {
+	text   string
+	steps  []jsonPathStep
+	isPath bool
+}
+
+// jsonPathStep selects a field, an index or all the children of the current values
+type jsonPathStep struct {
+	field    string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath parses a template with JSONPath expressions between braces
+func parseJSONPath(text string) (*jsonPath, error) {
+	path := &jsonPath{}
+	for len(text) > 0 {
+		start := strings.Index(text, "{")
+		if start == -1 {
+			path.segments = append(path.segments, jsonPathSegment{text: text})
+			break
+		}
+		if start > 0 {
+			path.segments = append(path.segments, jsonPathSegment{text: text[:start]})
+		}
+		end := strings.Index(text[start:], "}")
+		if end == -1 {
+			return nil, fmt.Errorf("unclosed expression in '%s'", text)
+		}
+		expression := strings.TrimSpace(text[start+1 : start+end])
+		text = text[start+end+1:]
+
+		if strings.HasPrefix(expression, `"`) { // Quoted literals allow printing characters like '{"\n"}'
+			literal, err := strconv.Unquote(expression)
+			if err != nil {
+				return nil, fmt.Errorf("invalid literal %s", expression)
+			}
+			path.segments = append(path.segments, jsonPathSegment{text: literal})
+			continue
+		}
+		steps, err := parseJSONPathSteps(expression)
+		if err != nil {
+			return nil, err
+		}
+		path.segments = append(path.segments, jsonPathSegment{steps: steps, isPath: true})
+	}
+	return path, nil
+}
+
+// parseJSONPathSteps parses an expression like '$[*].criteria[0].check' into the steps to evaluate
+func parseJSONPathSteps(expression string) ([]jsonPathStep, error) {
+	var steps []jsonPathStep
+	rest := strings.TrimPrefix(expression, "$")
+	if rest != "" && rest[0] != '.' && rest[0] != '[' { // Allows omitting the leading dot like in '{name}'
+		rest = "." + rest
+	}
+
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			if strings.HasPrefix(rest, ".") {
+				return nil, fmt.Errorf("recursive descent is not supported in '%s'", expression)
+			}
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			field := rest[:end]
+			rest = rest[end:]
+			if field == "*" {
+				steps = append(steps, jsonPathStep{wildcard: true})
+			} else if field != "" {
+				steps = append(steps, jsonPathStep{field: field})
+			} else if rest != "" && rest[0] == '.' {
+				return nil, fmt.Errorf("empty field in '%s'", expression)
+			}
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed brackets in '%s'", expression)
+			}
+			selector := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			switch {
+			case selector == "*":
+				steps = append(steps, jsonPathStep{wildcard: true})
+			case len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0]:
+				steps = append(steps, jsonPathStep{field: selector[1 : len(selector)-1]})
+			default:
+				index, err := strconv.Atoi(selector)
+				if err != nil {
+					return nil, fmt.Errorf("unsupported selector [%s] in '%s'", selector, expression)
+				}
+				steps = append(steps, jsonPathStep{index: index, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("unexpected '%c' in '%s'", rest[0], expression)
+		}
+	}
+	return steps, nil
+}
+
+// execute evaluates the template against the value and writes the result. The values
+// matched by each expression are separated by spaces, and maps and lists are printed as JSON.
+func (p *jsonPath) execute(out io.Writer, value interface{}) error {
+	for _, segment := range p.segments {
+		if !segment.isPath {
+			if _, err := io.WriteString(out, segment.text); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var texts []string
+		for _, match := range evaluateJSONPath(segment.steps, value) {
+			text, err := formatJSONPathValue(match)
+			if err != nil {
+				return err
+			}
+			texts = append(texts, text)
+		}
+		if _, err := io.WriteString(out, strings.Join(texts, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluateJSONPath returns all values matched by the steps. Fields or indexes that don't exist match nothing.
+func evaluateJSONPath(steps []jsonPathStep, value interface{}) []interface{} {
+	current := []interface{}{value}
+	for _, step := range steps {
+		var next []interface{}
+		for _, value := range current {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				if step.wildcard {
+					for _, key := range sortedMapKeys(v) {
+						next = append(next, v[key])
+					}
+				} else if fieldValue, found := v[step.field]; found && !step.isIndex {
+					next = append(next, fieldValue)
+				}
+			case []interface{}:
+				if step.wildcard {
+					next = append(next, v...)
+				} else if step.isIndex {
+					index := step.index
+					if index < 0 {
+						index += len(v)
+					}
+					if index >= 0 && index < len(v) {
+						next = append(next, v[index])
+					}
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// formatJSONPathValue prints strings and numbers as they are, and maps and lists as JSON
+func formatJSONPathValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case map[string]interface{}, []interface{}:
+		valueInBytes, err := json.Marshal(v)
+		return string(valueInBytes), err
+	}
+	return fmt.Sprint(value), nil
+}
+
+// sortedMapKeys returns the keys of the map sorted, so wildcards match fields in a stable order
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,45 @@
+package command
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestJSONPathExecute(t *testing.T) {
+	value := []interface{}{
+		map[string]interface{}{"name": "docker", "criteria": []interface{}{map[string]interface{}{"check": "docker", "threshold": 90}}},
+		map[string]interface{}{"name": "kafka", "enabled": false},
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"{[*].name}", "docker kafka"},
+		{"{$[*].name}", "docker kafka"},
+		{"{.[0].name}", "docker"},
+		{"{[-1].name}", "kafka"},
+		{"{[5].name}", ""},
+		{"{[*].criteria[*].threshold}", "90"},
+		{"{[1].enabled}", "false"},
+		{"{[1]['name']}", "kafka"},
+		{"{[0].criteria}", `[{"check":"docker","threshold":90}]`},
+		{"{[1].*}", "false kafka"},
+		{`names: {[0].name}, {[1].name}{"\n"}`, "names: docker, kafka\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.template, func(t *testing.T) {
+			path, err := parseJSONPath(test.template)
+			if err != nil {
+				t.Fatalf("parseJSONPath() error = %v", err)
+			}
+			var out bytes.Buffer
+			if err := path.execute(&out, value); err != nil {
+				t.Fatalf("execute() error = %v", err)
+			}
+			if got := out.String(); got != test.want {
+				t.Errorf("execute() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/outlyerapp/outlyer-cli/config"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// lintConfigFile is the project configuration of the linter, read from the current folder by default
+const lintConfigFile = ".outlyer-lint.yaml"
+
+// Severities of the lint findings. Only errors make the lint command fail.
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+	severityInfo    = "info"
+)
+
+// Rules checked by the linter, identified by the IDs used to configure and suppress them
+const (
+	ruleMissingCheck     = "missing-check"
+	ruleMissingPlugin    = "missing-plugin"
+	ruleMissingView      = "missing-view"
+	ruleMissingReference = "missing-reference"
+	ruleDuplicateName    = "duplicate-name"
+	ruleNameMismatch     = "name-mismatch"
+	ruleInvalidResource  = "invalid-resource"
+)
+
+// lintRules maps the ID of every rule to its default severity and description
+var lintRules = map[string]struct{ severity, description string }{
+	ruleMissingCheck:     {severityError, "alerts evaluating checks that don't exist locally or in the account"},
+	ruleMissingPlugin:    {severityError, "checks running plugins that don't exist locally or in the account"},
+	ruleMissingView:      {severityError, "dashboards showing views that don't exist locally or in the account"},
+	ruleMissingReference: {severityWarning, "any other reference to a resource that doesn't exist locally or in the account"},
+	ruleDuplicateName:    {severityError, "resources of the same type with the same name in different files"},
+	ruleNameMismatch:     {severityWarning, "resources whose name is not the name of their file"},
+	ruleInvalidResource:  {severityError, "resources that are not valid YAML, which can't be linted"},
+}
+
+// lintConfig is the project configuration of the linter, which disables rules, changes their severity
+// and suppresses their findings for some resources
+type lintConfig struct {
+	Disable  []string          `yaml:"disable"`
+	Severity map[string]string `yaml:"severity"`
+	Ignore   []lintIgnore      `yaml:"ignore"`
+}
+
+// lintIgnore suppresses the findings of a rule, or of all rules if no rule is set, for the resources
+// matching any of the glob patterns, like 'alerts/legacy-*'
+type lintIgnore struct {
+	Rule      string   `yaml:"rule"`
+	Resources []string `yaml:"resources"`
+}
+
+// lintFinding is a problem found by a rule in a resource
+type lintFinding struct {
+	rule     string
+	severity string
+	path     string
+	line     int
+	resource string
+	message  string
+}
+
+// lintColumns are the columns of the table listing the lint findings
+var lintColumns = []column{
+	{header: "SEVERITY", fields: []string{"severity"}},
+	{header: "RULE", fields: []string{"rule"}},
+	{header: "FILE", fields: []string{"file"}},
+	{header: "MESSAGE", fields: []string{"message"}},
+}
+
+// NewLintCommand creates a Command for finding broken references and naming problems across resource files
+func NewLintCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lint .|[folder]|[file]",
+		Short: "Finds broken references between resources, duplicate names and files not named like their resource. The available resources are: alerts, checks, dashboards, plugins and views",
+		Long: `Finds broken references between resources, duplicate names and files not named like their resource.
+
+References to resources that don't exist locally are looked up in the account set with --account or the
+default account, unless --offline is set. Each finding has the severity and the ID of the rule reporting it:
+
+` + formatLintRules() + `
+Rules are configured in the ` + lintConfigFile + ` file of the current folder, or the file set with --lint-config:
+
+disable:
+- name-mismatch
+severity:
+  missing-reference: error
+ignore:
+- rule: missing-check
+  resources: [alerts/legacy-*]
+
+The command fails if any finding has the error severity.`,
+		Example: `
+Lints all resources from inside the 'demo' directory, looking up missing references in the account:
+$ outlyer lint . --account=<your_account>
+
+Lints the effective resources of an overlay without accessing the Outlyer API:
+$ outlyer lint path_to/overlays/prod --offline`,
+		Run: lintCommand,
+	}
+
+	cmd.PersistentFlags().StringP("account", "a", "", "(Optional) User account to look up the references not found locally. Defaults to the 'default-account' configuration or the "+config.AccountEnv+" environment variable")
+	cmd.PersistentFlags().Bool("offline", false, "(Optional) Only looks up references locally, without accessing the Outlyer API")
+	cmd.PersistentFlags().String("lint-config", "", "(Optional) Project configuration of the linter. Defaults to "+lintConfigFile+" in the current folder, if it exists")
+	cmd.PersistentFlags().StringArray("values", nil, "(Optional) YAML file with the values to render templated resources with, like {{ .Values.threshold }}. Resources are only rendered when values are given. Can be repeated, later files override earlier ones")
+	cmd.PersistentFlags().StringArray("set", nil, "(Optional) Sets a value to render templated resources with, like --set threshold=90. Can be repeated and overrides the values files")
+	return cmd
+}
+
+// lintCommand lints the resources and lists the findings, failing if any of them is an error
+func lintCommand(cmd *cobra.Command, args []string) {
+	printer := newPrinter()
+	if len(args) < 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Resource is required"))
+	}
+
+	configPath := cmd.PersistentFlags().Lookup("lint-config").Value.String()
+	lintConfig, err := readLintConfig(configPath)
+	if err != nil {
+		ExitWithError(ExitBadArgs, err)
+	}
+
+	// Resources defined in several files are reported by the duplicate-name rule instead of failing to load
+	resources := readResources(args, getValues(cmd), false)
+	account := getAccount(cmd)
+	if offline, _ := cmd.PersistentFlags().GetBool("offline"); offline {
+		account = ""
+	}
+
+	remoteNames := make(map[string]map[string]bool)
+	lookUpRemote := func(resourceType string) map[string]bool {
+		if names, found := remoteNames[resourceType]; found {
+			return names
+		}
+		names, err := listResourceNames(account, resourceType)
+		if err != nil {
+			ExitWithError(getExitCode(err), fmt.Errorf("Could not fetch %s from account %s\n%s", resourceType, account, err))
+		}
+		remoteNames[resourceType] = make(map[string]bool)
+		for _, name := range names {
+			remoteNames[resourceType][name] = true
+		}
+		return remoteNames[resourceType]
+	}
+	if account == "" {
+		lookUpRemote = nil
+	}
+
+	findings := lintConfig.apply(lintResources(resources, lookUpRemote))
+	counts := make(map[string]int)
+	items := make([]map[string]interface{}, len(findings))
+	for i, finding := range findings {
+		file := finding.path
+		if finding.line > 0 {
+			file = fmt.Sprintf("%s:%d", finding.path, finding.line)
+		}
+		items[i] = map[string]interface{}{"severity": finding.severity, "rule": finding.rule, "file": file, "resource": finding.resource, "message": finding.message}
+		counts[finding.severity]++
+	}
+	if len(findings) == 0 && printer.isTable() {
+		fmt.Fprintf(printer.messages(), "Linted %d resources, no problems found\n", len(resources))
+		return
+	}
+	if err := printer.print(lintColumns, items); err != nil {
+		ExitWithError(ExitError, fmt.Errorf("Could not print lint findings\n%s", err))
+	}
+	fmt.Fprintf(printer.messages(), "\n%d errors, %d warnings, %d infos in %d resources\n", counts[severityError], counts[severityWarning], counts[severityInfo], len(resources))
+
+	if counts[severityError] > 0 {
+		os.Exit(ExitError)
+	}
+}
+
+// lintResources runs all rules on the resources. References not found locally are looked up with
+// lookUpRemote, which returns the names of the resources of a type in the account, if it's set.
+func lintResources(resources []resource, lookUpRemote func(resourceType string) map[string]bool) []lintFinding {
+	var findings []lintFinding
+	local := make(map[string]bool)
+	definitions := make([]map[interface{}]interface{}, len(resources))
+	namedBy := make(map[string][]int)
+
+	for i, resource := range resources {
+		local[resource.getAPIPath()] = true
+		if resource.getType() == Plugins {
+			continue
+		}
+		definition, err := decodeResource(resource.getType(), resource.bytes)
+		if err != nil {
+			findings = append(findings, lintFinding{rule: ruleInvalidResource, path: resource.path, resource: resource.getAPIPath(),
+				message: fmt.Sprintf("invalid resource: %s", err)})
+			continue
+		}
+		definitions[i] = definition
+
+		name, hasName := definition["name"]
+		if !hasName {
+			continue
+		}
+		typeAndName := resource.getType() + "/" + fmt.Sprint(name)
+		local[typeAndName] = true
+		namedBy[typeAndName] = append(namedBy[typeAndName], i)
+
+		if fileName := resource.getNameWithExtension(); getResourceFileName(resource.getType(), fmt.Sprint(name)) != fileName {
+			findings = append(findings, lintFinding{rule: ruleNameMismatch, path: resource.path, line: findLine(splitLines(string(resource.bytes)), []interface{}{"name"}),
+				resource: resource.getAPIPath(), message: fmt.Sprintf("the name is '%s' but the file is named '%s' instead of '%s'", name, fileName, getResourceFileName(resource.getType(), fmt.Sprint(name)))})
+		}
+	}
+
+	for typeAndName, indexes := range namedBy {
+		if len(indexes) < 2 {
+			continue
+		}
+		var paths []string
+		for _, i := range indexes {
+			paths = append(paths, resources[i].path)
+		}
+		for _, i := range indexes {
+			findings = append(findings, lintFinding{rule: ruleDuplicateName, path: resources[i].path, line: findLine(splitLines(string(resources[i].bytes)), []interface{}{"name"}),
+				resource: resources[i].getAPIPath(), message: fmt.Sprintf("%s is defined in %d files: %s", typeAndName, len(paths), strings.Join(paths, ", "))})
+		}
+	}
+
+	for i, definition := range definitions {
+		if definition == nil {
+			continue
+		}
+		for _, reference := range extractReferences(definition) {
+			if local[reference] {
+				continue
+			}
+			referenceType := reference[:strings.Index(reference, "/")]
+			if lookUpRemote != nil && lookUpRemote(referenceType)[reference] {
+				continue
+			}
+			where := "locally"
+			if lookUpRemote != nil {
+				where = "locally or in the account"
+			}
+			findings = append(findings, lintFinding{rule: getReferenceRule(resources[i].getType(), referenceType), path: resources[i].path,
+				resource: resources[i].getAPIPath(), message: fmt.Sprintf("references %s, which doesn't exist %s", reference, where)})
+		}
+	}
+
+	for i := range findings {
+		findings[i].severity = lintRules[findings[i].rule].severity
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].path != findings[j].path {
+			return findings[i].path < findings[j].path
+		}
+		return findings[i].line < findings[j].line
+	})
+	return findings
+}
+
+// getReferenceRule returns the rule reporting a broken reference from a resource of the given type
+func getReferenceRule(resourceType, referenceType string) string {
+	switch {
+	case resourceType == Alerts && referenceType == Checks:
+		return ruleMissingCheck
+	case resourceType == Checks && referenceType == Plugins:
+		return ruleMissingPlugin
+	case resourceType == Dashboards && referenceType == Views:
+		return ruleMissingView
+	}
+	return ruleMissingReference
+}
+
+// readLintConfig reads the project configuration of the linter from the given file, or from the
+// default file in the current folder if no file is given and it exists
+func readLintConfig(configPath string) (*lintConfig, error) {
+	if configPath == "" {
+		if !fileOrDirExists(lintConfigFile) {
+			return &lintConfig{}, nil
+		}
+		configPath = lintConfigFile
+	}
+
+	content, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read the lint configuration\n%s", err)
+	}
+	var c lintConfig
+	if err := yaml.UnmarshalStrict(content, &c); err != nil {
+		return nil, fmt.Errorf("%s: invalid lint configuration\n%s", configPath, err)
+	}
+
+	for _, rule := range c.Disable {
+		if _, found := lintRules[rule]; !found {
+			return nil, fmt.Errorf("%s: unknown rule '%s' in disable", configPath, rule)
+		}
+	}
+	for rule, severity := range c.Severity {
+		if _, found := lintRules[rule]; !found {
+			return nil, fmt.Errorf("%s: unknown rule '%s' in severity", configPath, rule)
+		}
+		if severity != severityError && severity != severityWarning && severity != severityInfo {
+			return nil, fmt.Errorf("%s: severity of %s must be error, warning or info, not '%s'", configPath, rule, severity)
+		}
+	}
+	for _, ignore := range c.Ignore {
+		if _, found := lintRules[ignore.Rule]; ignore.Rule != "" && !found {
+			return nil, fmt.Errorf("%s: unknown rule '%s' in ignore", configPath, ignore.Rule)
+		}
+		for _, pattern := range ignore.Resources {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%s: invalid resource pattern '%s' in ignore", configPath, pattern)
+			}
+		}
+	}
+	return &c, nil
+}
+
+// apply removes the findings of disabled rules and ignored resources, and sets the configured severities
+func (c *lintConfig) apply(findings []lintFinding) []lintFinding {
+	var kept []lintFinding
+	for _, finding := range findings {
+		if containsString(c.Disable, finding.rule) || c.isIgnored(finding) {
+			continue
+		}
+		if severity, found := c.Severity[finding.rule]; found {
+			finding.severity = severity
+		}
+		kept = append(kept, finding)
+	}
+	return kept
+}
+
+// isIgnored checks whether the finding is suppressed for its resource
+func (c *lintConfig) isIgnored(finding lintFinding) bool {
+	for _, ignore := range c.Ignore {
+		if ignore.Rule != "" && ignore.Rule != finding.rule {
+			continue
+		}
+		for _, pattern := range ignore.Resources {
+			if matched, _ := path.Match(pattern, finding.resource); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// formatLintRules describes every rule with its default severity, for the help of the lint command
+func formatLintRules() string {
+	var rules []string
+	for rule := range lintRules {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	var formatted string
+	for _, rule := range rules {
+		formatted += fmt.Sprintf("  %-18s %-8s %s\n", rule, lintRules[rule].severity, lintRules[rule].description)
+	}
+	return formatted
+}
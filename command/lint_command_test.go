@@ -0,0 +1,124 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLintResources(t *testing.T) {
+	resources := []resource{
+		{path: "demo/alerts/docker.yaml", bytes: []byte("name: docker\ncriteria:\n- check: docker\n- check: kafka\n")},
+		{path: "demo/alerts/disk.yaml", bytes: []byte("name: disk\ncriteria:\n- check: disk\n")},
+		{path: "demo/checks/docker.yaml", bytes: []byte("name: docker\ncommand: docker.py\n")},
+		{path: "demo/checks/docker-copy.yaml", bytes: []byte("# copied\nname: docker\ncommand: docker.py\n")},
+		{path: "demo/checks/redis.yaml", bytes: []byte("name: redis\nplugin: redis.py\n")},
+		{path: "demo/dashboards/docker.yaml", bytes: []byte("name: docker\nviews: [containers]\nwidgets:\n- alert: docker\n  dashboard: hosts\n")},
+		{path: "demo/plugins/docker.py", bytes: []byte("print('docker')")},
+		{path: "demo/views/hosts.yaml", bytes: []byte("name: [hosts\n")},
+	}
+
+	tests := []struct {
+		name   string
+		remote map[string]map[string]bool
+		want   []lintFinding
+	}{
+		{"offline", nil, []lintFinding{
+			{rule: ruleMissingCheck, severity: severityError, path: "demo/alerts/disk.yaml", resource: "alerts/disk", message: "references checks/disk, which doesn't exist locally"},
+			{rule: ruleMissingCheck, severity: severityError, path: "demo/alerts/docker.yaml", resource: "alerts/docker", message: "references checks/kafka, which doesn't exist locally"},
+			{rule: ruleNameMismatch, severity: severityWarning, path: "demo/checks/docker-copy.yaml", line: 2, resource: "checks/docker-copy", message: "the name is 'docker' but the file is named 'docker-copy.yaml' instead of 'docker.yaml'"},
+			{rule: ruleDuplicateName, severity: severityError, path: "demo/checks/docker-copy.yaml", line: 2, resource: "checks/docker-copy", message: "checks/docker is defined in 2 files: demo/checks/docker.yaml, demo/checks/docker-copy.yaml"},
+			{rule: ruleDuplicateName, severity: severityError, path: "demo/checks/docker.yaml", line: 1, resource: "checks/docker", message: "checks/docker is defined in 2 files: demo/checks/docker.yaml, demo/checks/docker-copy.yaml"},
+			{rule: ruleMissingPlugin, severity: severityError, path: "demo/checks/redis.yaml", resource: "checks/redis", message: "references plugins/redis.py, which doesn't exist locally"},
+			{rule: ruleMissingReference, severity: severityWarning, path: "demo/dashboards/docker.yaml", resource: "dashboards/docker", message: "references dashboards/hosts, which doesn't exist locally"},
+			{rule: ruleMissingView, severity: severityError, path: "demo/dashboards/docker.yaml", resource: "dashboards/docker", message: "references views/containers, which doesn't exist locally"},
+			{rule: ruleInvalidResource, severity: severityError, path: "demo/views/hosts.yaml", resource: "views/hosts", message: "invalid resource: yaml: line 1: did not find expected ',' or ']'"},
+		}},
+		{"looking up the account", map[string]map[string]bool{
+			Checks:     {"checks/kafka": true, "checks/disk": true},
+			Plugins:    {"plugins/redis.py": true},
+			Dashboards: {},
+			Views:      {"views/containers": true},
+		}, []lintFinding{
+			{rule: ruleNameMismatch, severity: severityWarning, path: "demo/checks/docker-copy.yaml", line: 2, resource: "checks/docker-copy", message: "the name is 'docker' but the file is named 'docker-copy.yaml' instead of 'docker.yaml'"},
+			{rule: ruleDuplicateName, severity: severityError, path: "demo/checks/docker-copy.yaml", line: 2, resource: "checks/docker-copy", message: "checks/docker is defined in 2 files: demo/checks/docker.yaml, demo/checks/docker-copy.yaml"},
+			{rule: ruleDuplicateName, severity: severityError, path: "demo/checks/docker.yaml", line: 1, resource: "checks/docker", message: "checks/docker is defined in 2 files: demo/checks/docker.yaml, demo/checks/docker-copy.yaml"},
+			{rule: ruleMissingReference, severity: severityWarning, path: "demo/dashboards/docker.yaml", resource: "dashboards/docker", message: "references dashboards/hosts, which doesn't exist locally or in the account"},
+			{rule: ruleInvalidResource, severity: severityError, path: "demo/views/hosts.yaml", resource: "views/hosts", message: "invalid resource: yaml: line 1: did not find expected ',' or ']'"},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var lookUpRemote func(string) map[string]bool
+			if test.remote != nil {
+				lookUpRemote = func(resourceType string) map[string]bool { return test.remote[resourceType] }
+			}
+			if got := lintResources(resources, lookUpRemote); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("lintResources() =\n%v\nwant\n%v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLintConfigApply(t *testing.T) {
+	findings := []lintFinding{
+		{rule: ruleMissingCheck, severity: severityError, resource: "alerts/legacy-disk"},
+		{rule: ruleMissingCheck, severity: severityError, resource: "alerts/docker"},
+		{rule: ruleNameMismatch, severity: severityWarning, resource: "checks/docker-copy"},
+		{rule: ruleMissingReference, severity: severityWarning, resource: "dashboards/docker"},
+		{rule: ruleDuplicateName, severity: severityError, resource: "views/legacy-hosts"},
+	}
+	c := &lintConfig{
+		Disable:  []string{ruleNameMismatch},
+		Severity: map[string]string{ruleMissingReference: severityError},
+		Ignore: []lintIgnore{
+			{Rule: ruleMissingCheck, Resources: []string{"alerts/legacy-*"}},
+			{Resources: []string{"views/*"}},
+		},
+	}
+	want := []lintFinding{
+		{rule: ruleMissingCheck, severity: severityError, resource: "alerts/docker"},
+		{rule: ruleMissingReference, severity: severityError, resource: "dashboards/docker"},
+	}
+	if got := c.apply(findings); !reflect.DeepEqual(got, want) {
+		t.Errorf("apply() = %v, want %v", got, want)
+	}
+}
+
+func TestReadLintConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"valid", "disable: [name-mismatch]\nseverity:\n  missing-reference: error\nignore:\n- rule: missing-check\n  resources: [alerts/legacy-*]\n", ""},
+		{"unknown field", "disabled: [name-mismatch]\n", "invalid lint configuration"},
+		{"unknown disabled rule", "disable: [missing-alert]\n", "unknown rule 'missing-alert' in disable"},
+		{"invalid severity", "severity:\n  name-mismatch: fatal\n", "severity of name-mismatch must be error, warning or info, not 'fatal'"},
+		{"invalid pattern", "ignore:\n- resources: ['alerts/[']\n", "invalid resource pattern 'alerts/[' in ignore"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configPath := filepath.Join(dir, lintConfigFile)
+			if err := ioutil.WriteFile(configPath, []byte(test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := readLintConfig(configPath)
+			if test.wantErr == "" && err != nil {
+				t.Errorf("readLintConfig() error = %v", err)
+			}
+			if test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)) {
+				t.Errorf("readLintConfig() error = %v, want containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package command
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// nameToFileName maps a resource name to a file name that is safe on any file system and can't escape
+// its folder. Characters other than ASCII letters, digits, '-', '_' and '.' are percent-encoded like in
+// URLs, along with dots at the start or the end of the name, so 'CPU / Memory' is written as
+// 'CPU%20%2F%20Memory'. fileNameToName reverses the mapping.
+func nameToFileName(name string) string {
+	var fileName string
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		isDotAtEdge := c == '.' && (i == 0 || i == len(name)-1)
+		if isSafeFileNameChar(c) && !isDotAtEdge {
+			fileName += string(c)
+		} else {
+			fileName += fmt.Sprintf("%%%02X", c)
+		}
+	}
+	return fileName
+}
+
+// isSafeFileNameChar checks whether the character can be used as it is in file names
+func isSafeFileNameChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.'
+}
+
+// fileNameToName returns the resource name of a file name mapped by nameToFileName.
+// File names that are not percent-encoded, like those written by hand, are names as they are.
+func fileNameToName(fileName string) string {
+	name, err := url.PathUnescape(fileName)
+	if err != nil {
+		return fileName
+	}
+	return name
+}
+
+// getResourceFileName returns the file name of a resource, like 'CPU%20Load.yaml' or 'docker.py' for plugins
+func getResourceFileName(resourceType, name string) string {
+	if resourceType == Plugins {
+		return nameToFileName(name)
+	}
+	return nameToFileName(name) + ".yaml"
+}
+
+// findCaseCollisions returns the groups of file names that only differ in case, which would
+// overwrite each other on case-insensitive file systems like the default ones of macOS and Windows
+func findCaseCollisions(fileNames []string) [][]string {
+	byLowerCase := make(map[string][]string)
+	var lowerCaseNames []string
+	for _, fileName := range removeDuplicates(fileNames) {
+		lower := strings.ToLower(fileName)
+		if _, found := byLowerCase[lower]; !found {
+			lowerCaseNames = append(lowerCaseNames, lower)
+		}
+		byLowerCase[lower] = append(byLowerCase[lower], fileName)
+	}
+	sort.Strings(lowerCaseNames)
+
+	var collisions [][]string
+	for _, lower := range lowerCaseNames {
+		if len(byLowerCase[lower]) > 1 {
+			sort.Strings(byLowerCase[lower])
+			collisions = append(collisions, byLowerCase[lower])
+		}
+	}
+	return collisions
+}
+
+// splitAPIPath splits a resource path used by the Outlyer API, like 'dashboards/CPU / Memory', into the
+// resource type and name. Since names may contain slashes, only the first slash separates them.
+func splitAPIPath(apiPath string) (string, string) {
+	slashIndex := strings.Index(apiPath, "/")
+	if slashIndex == -1 {
+		return apiPath, ""
+	}
+	return apiPath[:slashIndex], apiPath[slashIndex+1:]
+}
+
+// accountPath returns the endpoint of a resource type or a single resource, like 'alerts' or 'alerts/docker',
+// in the account, escaping the account and the resource name so any name reaches the right endpoint
+func accountPath(account, apiPath string) string {
+	endpoint := "/accounts/" + url.PathEscape(account)
+	if apiPath == "" {
+		return endpoint
+	}
+	resourceType, name := splitAPIPath(apiPath)
+	endpoint += "/" + url.PathEscape(resourceType)
+	if name != "" {
+		endpoint += "/" + url.PathEscape(name)
+	}
+	return endpoint
+}
+
+// readResourceName returns the name in the definition of the resource, which identifies it in the
+// Outlyer API regardless of the name of its file, or an empty name if the definition has none
+func readResourceName(res resource) string {
+	var definition struct {
+		Name interface{} `yaml:"name"`
+	}
+	if err := yaml.Unmarshal(res.bytes, &definition); err != nil || definition.Name == nil {
+		return ""
+	}
+	return fmt.Sprint(definition.Name)
+}
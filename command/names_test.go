@@ -0,0 +1,84 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNameToFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"docker", "docker"},
+		{"docker.py", "docker.py"},
+		{"CPU / Memory", "CPU%20%2F%20Memory"},
+		{"../../etc/passwd", "%2E.%2F..%2Fetc%2Fpasswd"},
+		{"..", "%2E%2E"},
+		{".hidden", "%2Ehidden"},
+		{"ends with.", "ends%20with%2E"},
+		{"100%", "100%25"},
+		{"café", "caf%C3%A9"},
+		{`C:\temp`, "C%3A%5Ctemp"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := nameToFileName(test.name)
+			if got != test.want {
+				t.Errorf("nameToFileName() = %v, want %v", got, test.want)
+			}
+			if name := fileNameToName(got); name != test.name {
+				t.Errorf("fileNameToName() = %v, want %v", name, test.name)
+			}
+		})
+	}
+}
+
+func TestFileNameToName(t *testing.T) {
+	tests := []struct {
+		fileName string
+		want     string
+	}{
+		{"docker", "docker"},
+		{"CPU%20Load", "CPU Load"},
+		{"50%-off", "50%-off"},
+	}
+	for _, test := range tests {
+		t.Run(test.fileName, func(t *testing.T) {
+			if got := fileNameToName(test.fileName); got != test.want {
+				t.Errorf("fileNameToName() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFindCaseCollisions(t *testing.T) {
+	got := findCaseCollisions([]string{"alerts/cpu.yaml", "alerts/disk.yaml", "alerts/CPU.yaml", "checks/cpu.yaml", "alerts/Cpu.yaml", "alerts/disk.yaml"})
+	want := [][]string{{"alerts/CPU.yaml", "alerts/Cpu.yaml", "alerts/cpu.yaml"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findCaseCollisions() = %v, want %v", got, want)
+	}
+}
+
+func TestAccountPath(t *testing.T) {
+	tests := []struct {
+		account string
+		apiPath string
+		want    string
+	}{
+		{"acme", "", "/accounts/acme"},
+		{"acme", "alerts", "/accounts/acme/alerts"},
+		{"acme", "alerts/docker", "/accounts/acme/alerts/docker"},
+		{"acme", "dashboards/CPU / Memory", "/accounts/acme/dashboards/CPU%20%2F%20Memory"},
+		{"acme", "dashboards/../../users", "/accounts/acme/dashboards/..%2F..%2Fusers"},
+		{"acme", "views/café?x=1", "/accounts/acme/views/caf%C3%A9%3Fx=1"},
+		{"my account", "plugins/docker.py", "/accounts/my%20account/plugins/docker.py"},
+	}
+	for _, test := range tests {
+		t.Run(test.apiPath, func(t *testing.T) {
+			if got := accountPath(test.account, test.apiPath); got != test.want {
+				t.Errorf("accountPath() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
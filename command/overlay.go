@@ -0,0 +1,316 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// overlayFile is the file that makes a folder an overlay of other resource folders
+const overlayFile = "overlay.yaml"
+
+// overlay composes the resources of its base folders, which may be overlays themselves, with the
+// resources in its own resource folders, replacing the base resources with the same name. It then
+// removes and patches the resulting resources, so each environment only declares what it changes.
+type overlay struct {
+	Bases   []string       `yaml:"bases"`
+	Remove  []string       `yaml:"remove"`
+	Patches []overlayPatch `yaml:"patches"`
+}
+
+// overlayPatch patches a resource, like 'alerts/docker', with a strategic merge patch, a JSON patch or both
+type overlayPatch struct {
+	Target string                      `yaml:"target"`
+	Merge  map[interface{}]interface{} `yaml:"merge"`
+	JSON   []jsonPatchOperation        `yaml:"json"`
+}
+
+// isOverlay checks whether the path is a folder with an overlay file
+func isOverlay(path string) bool {
+	fileInfo, err := os.Stat(filepath.Join(path, overlayFile))
+	return err == nil && !fileInfo.IsDir()
+}
+
+// readOverlay reads the overlay file of the folder. Unlike resources, overlays are not templated,
+// since they already are specific to an environment.
+func readOverlay(dir string) (*overlay, error) {
+	path := filepath.Join(dir, overlayFile)
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var o overlay
+	if err := yaml.UnmarshalStrict(content, &o); err != nil {
+		return nil, fmt.Errorf("%s: invalid overlay\n%s", path, err)
+	}
+	if len(o.Bases) == 0 {
+		return nil, fmt.Errorf("%s: an overlay requires at least one folder in 'bases'", path)
+	}
+	return &o, nil
+}
+
+// loadResources reads the resources of the given files and folders, computing the effective
+// resources of the overlays among them, so they go through the same pipeline as any other resource.
+// It exits if the same resource is included twice, by different files or overlays, and, unless allowEmpty
+// is set, if there are no resources at all.
+func loadResources(args []string, values map[interface{}]interface{}, allowEmpty bool) []resource {
+	resources := readResources(args, values, allowEmpty)
+	included := make(map[string]string)
+	for _, resource := range resources {
+		if other, found := included[resource.getAPIPath()]; found {
+			ExitWithError(ExitBadArgs, fmt.Errorf("%s is included twice, by %s and %s", resource.getAPIPath(), other, resource.path))
+		}
+		included[resource.getAPIPath()] = resource.path
+	}
+	return resources
+}
+
+// readResources reads the resources of the given files and folders like loadResources,
+// but keeps all of them even if the same resource is included twice
+func readResources(args []string, values map[interface{}]interface{}, allowEmpty bool) []resource {
+	var plainArgs []string
+	var overlayResources []resource
+	for _, arg := range args {
+		if !isOverlay(arg) {
+			plainArgs = append(plainArgs, arg)
+			continue
+		}
+		built, err := buildOverlay(arg, values, make(map[string]bool))
+		if err != nil {
+			ExitWithError(ExitError, fmt.Errorf("Could not build overlay %s\n%s", arg, err))
+		}
+		overlayResources = append(overlayResources, built...)
+	}
+
+	var resources []resource
+	if len(plainArgs) > 0 {
+		resources = getResources(getPaths(plainArgs, allowEmpty), values)
+	}
+	return append(resources, overlayResources...)
+}
+
+// buildOverlay returns the effective resources of the overlay folder. Overlays being built are
+// tracked so an overlay that is its own base, directly or through other overlays, is reported.
+func buildOverlay(dir string, values map[interface{}]interface{}, building map[string]bool) ([]resource, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if building[absDir] {
+		return nil, fmt.Errorf("%s: the overlay is a base of itself", dir)
+	}
+	building[absDir] = true
+	defer delete(building, absDir)
+
+	o, err := readOverlay(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resources are kept in the order of their bases, followed by the ones added by the overlay
+	var names []string
+	resources := make(map[string]resource)
+	add := func(added []resource) {
+		for _, resource := range added {
+			name := resource.getAPIPath()
+			if _, found := resources[name]; !found {
+				names = append(names, name)
+			}
+			resources[name] = resource
+		}
+	}
+
+	for _, base := range o.Bases {
+		baseDir := base
+		if !filepath.IsAbs(base) {
+			baseDir = filepath.Join(dir, base)
+		}
+		if isOverlay(baseDir) {
+			baseResources, err := buildOverlay(baseDir, values, building)
+			if err != nil {
+				return nil, err
+			}
+			add(baseResources)
+			continue
+		}
+		if !fileOrDirExists(baseDir) {
+			return nil, fmt.Errorf("base %s: no such file or directory", baseDir)
+		}
+		add(getResources(getPaths([]string{baseDir}, false), values))
+	}
+	if paths := getOverlayPaths(dir); len(paths) > 0 {
+		add(getResources(paths, values))
+	}
+
+	for _, name := range o.Remove {
+		if _, found := resources[name]; !found {
+			return nil, fmt.Errorf("remove %s: not found in the bases of the overlay", name)
+		}
+		delete(resources, name)
+	}
+
+	for i, patch := range o.Patches {
+		resource, found := resources[patch.Target]
+		if !found {
+			return nil, fmt.Errorf("patch %d: %s not found in the bases of the overlay", i+1, patch.Target)
+		}
+		patched, err := patchResource(resource, patch)
+		if err != nil {
+			return nil, fmt.Errorf("patch %d: %s: %s", i+1, patch.Target, err)
+		}
+		// The patched resource is no longer the content of its file, so it's shown as part of the overlay
+		patched.path = filepath.Join(dir, patched.getTypeAndNameWithExtension())
+		patched.name = readResourceName(patched)
+		resources[patch.Target] = patched
+	}
+
+	var effective []resource
+	for _, name := range names {
+		if resource, found := resources[name]; found {
+			effective = append(effective, resource)
+		}
+	}
+	return effective, nil
+}
+
+// getOverlayPaths returns the files in the resource folders of the overlay, like getPaths for a folder
+// with resource folders, but without failing when the overlay adds no resources
+func getOverlayPaths(dir string) []string {
+	var paths []string
+	for _, resourceType := range resourceTypes {
+		files, _ := ioutil.ReadDir(filepath.Join(dir, resourceType))
+		for _, file := range files {
+			if !file.IsDir() {
+				paths = append(paths, filepath.Join(dir, resourceType, file.Name()))
+			}
+		}
+	}
+	return paths
+}
+
+// getOverlayTypes returns the resource types whose whole folder is included by the overlay, either
+// through its bases or its own resource folders, so pruning an overlay works like pruning a folder
+func getOverlayTypes(dir string) []string {
+	o, err := readOverlay(dir)
+	if err != nil {
+		return nil
+	}
+	var args []string
+	for _, base := range o.Bases {
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(dir, base)
+		}
+		args = append(args, base)
+	}
+	types := getIncludedTypes(args)
+	for _, resourceType := range resourceTypes {
+		if fileOrDirExists(filepath.Join(dir, resourceType)) {
+			types = append(types, resourceType)
+		}
+	}
+	return removeDuplicates(types)
+}
+
+// patchResource applies the strategic merge patch and then the JSON patch to the resource definition
+func patchResource(res resource, patch overlayPatch) (resource, error) {
+	if res.getType() == Plugins {
+		return res, fmt.Errorf("plugins can't be patched, replace the plugin in the overlay instead")
+	}
+	if patch.Merge == nil && patch.JSON == nil {
+		return res, fmt.Errorf("a patch requires 'merge' or 'json'")
+	}
+
+	definition, err := decodeResource(res.getType(), res.bytes)
+	if err != nil {
+		return res, fmt.Errorf("invalid resource: %s", err)
+	}
+	var patched interface{} = definition
+	if patch.Merge != nil {
+		patched = mergePatch(patched, patch.Merge)
+	}
+	if patch.JSON != nil {
+		if patched, err = applyJSONPatch(patched, patch.JSON); err != nil {
+			return res, err
+		}
+	}
+
+	if res.bytes, err = yaml.Marshal(patched); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// mergePatch merges the patch into the value like a strategic merge patch: objects are merged field by
+// field, null fields are removed, lists of objects with a name are merged by name, where an item with
+// '$patch: delete' is removed, and any other value is replaced by the patch
+func mergePatch(value, patch interface{}) interface{} {
+	switch p := patch.(type) {
+	case map[interface{}]interface{}:
+		merged := make(map[interface{}]interface{})
+		if v, ok := value.(map[interface{}]interface{}); ok {
+			for key, fieldValue := range v {
+				merged[key] = fieldValue
+			}
+		}
+		for key, fieldPatch := range p {
+			if fieldPatch == nil {
+				delete(merged, key)
+				continue
+			}
+			merged[key] = mergePatch(merged[key], fieldPatch)
+		}
+		return merged
+	case []interface{}:
+		if v, ok := value.([]interface{}); ok && hasNamedItems(v) && hasNamedItems(p) {
+			return mergeNamedItems(v, p)
+		}
+	}
+	return copyValue(patch)
+}
+
+// hasNamedItems checks whether all items of the list are objects with a name
+func hasNamedItems(list []interface{}) bool {
+	for _, item := range list {
+		object, ok := item.(map[interface{}]interface{})
+		if !ok || object["name"] == nil {
+			return false
+		}
+	}
+	return len(list) > 0
+}
+
+// mergeNamedItems merges each item of the patch into the item of the list with the same name,
+// appending the items the list doesn't have
+func mergeNamedItems(list, patch []interface{}) []interface{} {
+	merged := make([]interface{}, len(list))
+	copy(merged, list)
+	for _, item := range patch {
+		itemPatch := copyValue(item).(map[interface{}]interface{})
+		name := fmt.Sprint(itemPatch["name"])
+		deleteItem := strings.ToLower(fmt.Sprint(itemPatch["$patch"])) == "delete"
+		delete(itemPatch, "$patch")
+
+		found := false
+		for i := 0; i < len(merged); i++ {
+			if fmt.Sprint(merged[i].(map[interface{}]interface{})["name"]) != name {
+				continue
+			}
+			found = true
+			if deleteItem {
+				merged = append(merged[:i], merged[i+1:]...)
+				i--
+			} else {
+				merged[i] = mergePatch(merged[i], itemPatch)
+			}
+		}
+		if !found && !deleteItem {
+			merged = append(merged, itemPatch)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,139 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		patch string
+		want  string
+	}{
+		{"fields", "name: docker\nwarning: 80\ndescription: old", "warning: 90\ndescription: null", "name: docker\nwarning: 90"},
+		{"nested fields", "notify:\n  channel: dev\n  email: dev@example.com", "notify:\n  channel: ops", "notify:\n  channel: ops\n  email: dev@example.com"},
+		{"named items", "criteria:\n- name: cpu\n  threshold: 80\n- name: memory\n  threshold: 70",
+			"criteria:\n- name: memory\n  threshold: 90\n- name: disk\n  threshold: 95",
+			"criteria:\n- name: cpu\n  threshold: 80\n- name: memory\n  threshold: 90\n- name: disk\n  threshold: 95"},
+		{"deleted item", "criteria:\n- name: cpu\n- name: memory", "criteria:\n- name: cpu\n  $patch: delete", "criteria:\n- name: memory"},
+		{"unnamed items", "hosts:\n- web-1\n- web-2", "hosts:\n- db-1", "hosts:\n- db-1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var value, patch, want interface{}
+			yaml.Unmarshal([]byte(test.value), &value)
+			yaml.Unmarshal([]byte(test.patch), &patch)
+			yaml.Unmarshal([]byte(test.want), &want)
+			if got := mergePatch(value, patch); !reflect.DeepEqual(got, want) {
+				t.Errorf("mergePatch() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	document := "name: docker\ncriteria:\n- threshold: 80\n- threshold: 70\nlabels:\n  a/b: x"
+
+	tests := []struct {
+		name       string
+		operations string
+		want       string
+		wantErr    string
+	}{
+		{"replace", "- {op: replace, path: /criteria/1/threshold, value: 95}", "name: docker\ncriteria:\n- threshold: 80\n- threshold: 95\nlabels:\n  a/b: x", ""},
+		{"add and remove", "- {op: add, path: /criteria/-, value: {threshold: 60}}\n- {op: remove, path: /criteria/0}\n- {op: add, path: /enabled, value: false}",
+			"name: docker\nenabled: false\ncriteria:\n- threshold: 70\n- threshold: 60\nlabels:\n  a/b: x", ""},
+		{"escaped key", "- {op: replace, path: /labels/a~1b, value: y}", "name: docker\ncriteria:\n- threshold: 80\n- threshold: 70\nlabels:\n  a/b: y", ""},
+		{"move", "- {op: move, from: /name, path: /description}", "description: docker\ncriteria:\n- threshold: 80\n- threshold: 70\nlabels:\n  a/b: x", ""},
+		{"test", "- {op: test, path: /name, value: kafka}", "", "operation 1 (test /name)"},
+		{"missing field", "- {op: replace, path: /interval, value: 30}", "", "interval: not found"},
+		{"invalid index", "- {op: remove, path: /criteria/2}", "", "2: invalid array index"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var value, want interface{}
+			var operations []jsonPatchOperation
+			yaml.Unmarshal([]byte(document), &value)
+			yaml.Unmarshal([]byte(test.operations), &operations)
+			yaml.Unmarshal([]byte(test.want), &want)
+
+			got, err := applyJSONPatch(value, operations)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("applyJSONPatch() error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("applyJSONPatch() = %v, %v, want %v", got, err, want)
+			}
+		})
+	}
+}
+
+func TestBuildOverlay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"base/alerts/docker.yaml":           "name: docker\nwarning: 80\n",
+		"base/alerts/legacy.yaml":           "name: legacy\n",
+		"base/checks/docker.yaml":           "name: docker\ninterval: 60\n",
+		"overlays/prod/checks/docker.yaml":  "name: docker\ninterval: 30\n",
+		"overlays/prod/views/docker.yaml":   "name: docker\n",
+		"overlays/prod/overlay.yaml":        "bases:\n- ../../base\nremove:\n- alerts/legacy\npatches:\n- target: alerts/docker\n  merge:\n    warning: 95\n",
+		"overlays/loop/overlay.yaml":        "bases:\n- ../loop\n",
+		"overlays/bad-patch/overlay.yaml":   "bases:\n- ../../base\npatches:\n- target: alerts/missing\n  merge:\n    warning: 95\n",
+		"overlays/unknown-key/overlay.yaml": "bases:\n- ../../base\npatch:\n- target: alerts/docker\n",
+	}
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		ioutil.WriteFile(path, []byte(content), 0644)
+	}
+
+	resources, err := buildOverlay(filepath.Join(dir, "overlays/prod"), nil, make(map[string]bool))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, resource := range resources {
+		got[resource.getAPIPath()] = string(resource.bytes)
+	}
+	want := map[string]string{
+		"alerts/docker": "name: docker\nwarning: 95\n",
+		"checks/docker": "name: docker\ninterval: 30\n",
+		"views/docker":  "name: docker\n",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildOverlay() = %v, want %v", got, want)
+	}
+
+	errorTests := []struct {
+		overlay string
+		wantErr string
+	}{
+		{"overlays/loop", "the overlay is a base of itself"},
+		{"overlays/bad-patch", "patch 1: alerts/missing not found"},
+		{"overlays/unknown-key", "invalid overlay"},
+	}
+	for _, test := range errorTests {
+		t.Run(test.overlay, func(t *testing.T) {
+			_, err := buildOverlay(filepath.Join(dir, test.overlay), nil, make(map[string]bool))
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("buildOverlay() error = %v, want it to contain %q", err, test.wantErr)
+			}
+		})
+	}
+}
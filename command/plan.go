@@ -0,0 +1,376 @@
+package command
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/outlyerapp/outlyer-cli/api"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	actionCreate = "CREATE"
+	actionUpdate = "UPDATE"
+	actionDelete = "DELETE"
+	actionNoop   = "NO-OP"
+)
+
+// fieldChange describes a single field that differs between the remote and the local resource
+type fieldChange struct {
+	kind byte // '+' for added fields, '-' for removed fields and '~' for updated fields
+	path string
+	from interface{}
+	to   interface{}
+}
+
+func (c fieldChange) String() string {
+	switch c.kind {
+	case '+':
+		return fmt.Sprintf("+ %s: %s", c.path, formatValue(c.to))
+	case '-':
+		return fmt.Sprintf("- %s: %s", c.path, formatValue(c.from))
+	default:
+		return fmt.Sprintf("~ %s: %s => %s", c.path, formatValue(c.from), formatValue(c.to))
+	}
+}
+
+// planResources classifies concurrently all resources against the user account
+func planResources(pool *workerPool, account string, resources []resource) {
+	pool.run(len(resources), func(i int) string {
+		classify(account, &resources[i])
+		return "planned " + resources[i].getTypeAndNameWithExtension()
+	})
+}
+
+// classify fetches the remote version of the resource and decides whether applying it
+// creates a new resource, updates the existing one or leaves it untouched.
+// Since resources are updated with PATCH, fields only present remotely are not considered changes,
+// and fields managed by the Outlyer API like ids and timestamps are ignored.
+// Resources marked to be deleted are left untouched if they no longer exist.
+func classify(account string, resource *resource) {
+	if resource.action == actionDelete {
+		resp, err := api.Get(accountPath(account, resource.getAPIPath()) + "?view=export")
+		if api.IsNotFound(err) {
+			resource.action = actionNoop
+		} else if err != nil {
+			resource.err = err
+		} else {
+			resource.remote = resp
+			resource.fingerprint = fingerprint(resp)
+		}
+		return
+	}
+
+	local, err := decodeCanonical(resource.getType(), resource.bytes)
+	if err != nil {
+		resource.err = fmt.Errorf("invalid local resource: %s", err)
+		return
+	}
+
+	resp, err := api.Get(accountPath(account, resource.getAPIPath()) + "?view=export")
+	if api.IsNotFound(err) {
+		resource.action = actionCreate
+		resource.fingerprint = ""
+		return
+	}
+	if err != nil {
+		resource.err = err
+		return
+	}
+	resource.remote = resp
+	resource.fingerprint = fingerprint(resp)
+
+	remote, err := decodeCanonical(resource.getType(), resp)
+	if err != nil {
+		resource.err = fmt.Errorf("invalid remote resource: %s", err)
+		return
+	}
+
+	resource.changes = nil
+	for _, key := range sortedKeys(local) {
+		remoteValue, found := remote[key]
+		if !found {
+			resource.changes = append(resource.changes, fieldChange{kind: '+', path: fmt.Sprint(key), to: local[key]})
+			continue
+		}
+		resource.changes = append(resource.changes, compareValues(fmt.Sprint(key), remoteValue, local[key])...)
+	}
+
+	if len(resource.changes) > 0 {
+		resource.action = actionUpdate
+	} else {
+		resource.action = actionNoop
+	}
+}
+
+// getRemoteOnlyNames lists the remote resources that have no matching local resource, like 'alerts/docker',
+// with listNames listing the remote resources of a type. Only resource folders entirely included by args are considered.
+func getRemoteOnlyNames(args []string, resources []resource, listNames func(resourceType string) []string) []string {
+	localResources := make(map[string]bool)
+	for _, resource := range resources {
+		localResources[resource.getAPIPath()] = true
+	}
+
+	var remoteOnly []string
+	for _, resourceType := range getIncludedTypes(args) {
+		for _, name := range listNames(resourceType) {
+			if !localResources[name] {
+				remoteOnly = append(remoteOnly, name)
+			}
+		}
+	}
+	return remoteOnly
+}
+
+// listRemoteNames returns a function listing the resources of a type in the account, like 'alerts/docker',
+// which exits if they can't be fetched
+func listRemoteNames(account string) func(resourceType string) []string {
+	return func(resourceType string) []string {
+		names, err := listResourceNames(account, resourceType)
+		if err != nil {
+			ExitWithError(getExitCode(err), fmt.Errorf("Could not fetch %s from account %s\n%s", resourceType, account, err))
+		}
+		return names
+	}
+}
+
+// getPrunedResources marks to be deleted all remote resources that no longer exist locally
+func getPrunedResources(args []string, resources []resource, listNames func(resourceType string) []string) []resource {
+	var pruned []resource
+	for _, apiPath := range getRemoteOnlyNames(args, resources, listNames) {
+		_, name := splitAPIPath(apiPath)
+		pruned = append(pruned, resource{path: apiPath, name: name, status: "FAIL", action: actionDelete})
+	}
+	return pruned
+}
+
+// fingerprint identifies the remote state of a resource regardless of key order and formatting
+func fingerprint(content []byte) string {
+	var definition interface{}
+	yaml.Unmarshal(content, &definition)
+	canonical, _ := yaml.Marshal(definition)
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(canonical))
+}
+
+// decodeResource parses a resource definition. Plugins are decoded to their source code.
+func decodeResource(resourceType string, content []byte) (map[interface{}]interface{}, error) {
+	if resourceType == Plugins {
+		var p plugin
+		if err := yaml.Unmarshal(content, &p); err != nil {
+			return nil, err
+		}
+		source, err := base64.StdEncoding.DecodeString(p.Content)
+		if err != nil {
+			return nil, err
+		}
+		return map[interface{}]interface{}{"content": string(source)}, nil
+	}
+
+	definition := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal(content, &definition); err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
+
+// compareValues returns the field-level changes from the remote value to the local one, descending into maps
+// and lists. Like the top-level fields compared by classify, fields of nested maps only present remotely are
+// not changes, since PATCH merges maps and keeps them. List items are compared one by one, since PATCH
+// replaces whole lists, so the remote items not present locally are removed.
+func compareValues(path string, from, to interface{}) []fieldChange {
+	var changes []fieldChange
+
+	switch toValue := to.(type) {
+	case map[interface{}]interface{}:
+		if fromValue, ok := from.(map[interface{}]interface{}); ok {
+			for _, key := range sortedKeys(toValue) {
+				fromField, inFrom := fromValue[key]
+				toField := toValue[key]
+				fieldPath := path + "." + fmt.Sprint(key)
+				switch {
+				case !inFrom:
+					changes = append(changes, fieldChange{kind: '+', path: fieldPath, to: toField})
+				default:
+					changes = append(changes, compareValues(fieldPath, fromField, toField)...)
+				}
+			}
+			return changes
+		}
+	case []interface{}:
+		if fromValue, ok := from.([]interface{}); ok {
+			for i := 0; i < len(fromValue) || i < len(toValue); i++ {
+				itemPath := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(toValue):
+					changes = append(changes, fieldChange{kind: '-', path: itemPath, from: fromValue[i]})
+				case i >= len(fromValue):
+					changes = append(changes, fieldChange{kind: '+', path: itemPath, to: toValue[i]})
+				default:
+					changes = append(changes, compareValues(itemPath, fromValue[i], toValue[i])...)
+				}
+			}
+			return changes
+		}
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		changes = append(changes, fieldChange{kind: '~', path: path, from: from, to: to})
+	}
+	return changes
+}
+
+// withoutRemoteOnlyFields returns a copy of the remote value without the fields of its maps that are not in the
+// local value, at every level, so they're ignored like compareValues ignores them. Lists keep all their items,
+// since PATCH replaces them, but the fields only present in the remote items are left out of them too.
+func withoutRemoteOnlyFields(from, to interface{}) interface{} {
+	switch toValue := to.(type) {
+	case map[interface{}]interface{}:
+		if fromValue, ok := from.(map[interface{}]interface{}); ok {
+			stripped := make(map[interface{}]interface{}, len(toValue))
+			for key, fromField := range fromValue {
+				if toField, inTo := toValue[key]; inTo {
+					stripped[key] = withoutRemoteOnlyFields(fromField, toField)
+				}
+			}
+			return stripped
+		}
+	case []interface{}:
+		if fromValue, ok := from.([]interface{}); ok {
+			stripped := make([]interface{}, len(fromValue))
+			for i, item := range fromValue {
+				if i < len(toValue) {
+					item = withoutRemoteOnlyFields(item, toValue[i])
+				}
+				stripped[i] = item
+			}
+			return stripped
+		}
+	}
+	return from
+}
+
+// printPlan lists what applying each resource would do to the given output
+func printPlan(out io.Writer, account string, resources []resource) {
+	counts := make(map[string]int)
+
+	fmt.Fprintf(out, "\nResources to apply to account '%s'...\n\n", account)
+	for _, resource := range resources {
+		if resource.err != nil {
+			fmt.Fprintf(out, "\t! %s (%s)\n", resource.path, resource.err)
+			continue
+		}
+		counts[resource.action]++
+		switch resource.action {
+		case actionCreate:
+			fmt.Fprintf(out, "\t+ %s (create)\n", resource.path)
+		case actionUpdate:
+			fmt.Fprintf(out, "\t~ %s (update)\n", resource.path)
+			for _, change := range resource.changes {
+				fmt.Fprintf(out, "\t    %s\n", change)
+			}
+		case actionDelete:
+			fmt.Fprintf(out, "\t- %s (delete)\n", resource.path)
+		default:
+			fmt.Fprintf(out, "\t= %s (no changes)\n", resource.path)
+		}
+	}
+	fmt.Fprintf(out, "\nPlan: %d to create, %d to update, %d to delete, %d unchanged.\n",
+		counts[actionCreate], counts[actionUpdate], counts[actionDelete], counts[actionNoop])
+}
+
+// planColumns are the fields of every planned resource printed by showPlan
+var planColumns = []column{
+	{header: "ACTION", fields: []string{"action"}},
+	{header: "RESOURCE", fields: []string{"resource"}},
+	{header: "FILE", fields: []string{"path"}},
+	{header: "REASON", fields: []string{"reason"}},
+}
+
+// showPlan prints the plan to stdout in the selected output format. Table output prints it as text with the
+// changes of every field, like printPlan, and other formats print the planned resources so scripts can read them.
+func showPlan(printer *printer, account string, resources []resource) {
+	if printer.isTable() {
+		printPlan(printer.out, account, resources)
+		return
+	}
+
+	items := make([]map[string]interface{}, len(resources))
+	for i, resource := range resources {
+		items[i] = getPlanItem(account, resource)
+	}
+	if err := printer.print(planColumns, items); err != nil {
+		ExitWithError(ExitError, fmt.Errorf("Could not print the plan\n%s", err))
+	}
+}
+
+// getPlanItem returns what applying the resource would do as printed by showPlan
+func getPlanItem(account string, resource resource) map[string]interface{} {
+	changes := make([]interface{}, len(resource.changes))
+	for i, change := range resource.changes {
+		changes[i] = change.String()
+	}
+	item := map[string]interface{}{
+		"account":  account,
+		"resource": resource.getAPIPath(),
+		"path":     resource.path,
+		"action":   resource.action,
+		"changes":  changes,
+		"reason":   "",
+	}
+	if resource.err != nil {
+		item["action"] = ""
+		item["reason"] = resource.err.Error()
+	}
+	return item
+}
+
+// hasChanges checks whether applying the resources would modify the account
+func hasChanges(resources []resource) bool {
+	for _, resource := range resources {
+		if resource.err == nil && resource.action != actionNoop {
+			return true
+		}
+	}
+	return false
+}
+
+// formatValue renders a field value in a compact single line format
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case map[interface{}]interface{}:
+		var fields []string
+		for _, key := range sortedKeys(v) {
+			fields = append(fields, fmt.Sprintf("%v: %s", key, formatValue(v[key])))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	case []interface{}:
+		var items []string
+		for _, item := range v {
+			items = append(items, formatValue(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// sortedKeys returns the keys of a YAML map sorted alphabetically
+func sortedKeys(m map[interface{}]interface{}) []interface{} {
+	keys := make([]interface{}, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
@@ -0,0 +1,166 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/outlyerapp/outlyer-cli/config"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// planFileVersion is the format version written to saved plans
+const planFileVersion = 1
+
+// savedPlan is the content of a plan file, which records the changes to apply to an account
+// and the remote state each change was computed against
+type savedPlan struct {
+	Version   int               `yaml:"version"`
+	Account   string            `yaml:"account"`
+	Resources []plannedResource `yaml:"resources"`
+}
+
+type plannedResource struct {
+	Path        string `yaml:"path"`
+	Name        string `yaml:"name,omitempty"`
+	Action      string `yaml:"action"`
+	Fingerprint string `yaml:"fingerprint,omitempty"`
+	Payload     string `yaml:"payload"`
+}
+
+// NewPlanCommand creates a Command for computing and saving the changes to apply to the user's Outlyer account
+func NewPlanCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan .|[folder]|[file]",
+		Short: "Shows which resources would be created, updated or deleted and optionally saves the plan to be applied later. The available resources are: alerts, checks, dashboards, plugins and views",
+		Example: `
+Shows what applying all resources from inside the 'demo' directory would change:
+$ outlyer plan . --account=<your_account>
+
+Shows what applying all resources with the production values and a threshold overridden would change:
+$ outlyer plan . --account=<your_account> --values=values/prod.yaml --set threshold=90
+
+Saves the plan for all resources in the 'demo' directory so it can be reviewed and applied later:
+$ outlyer plan path_to/demo --account=<your_account> --out=plan.out
+
+Also plans to delete all alerts and checks from the account that no longer exist locally:
+$ outlyer plan path_to/demo/alerts path_to/demo/checks --account=<your_account> --prune --out=plan.out
+
+Applies exactly the saved plan. It is refused if any resource changed in the account since the plan was created:
+$ outlyer apply plan.out`,
+		Run: planCommand,
+	}
+
+	cmd.PersistentFlags().StringP("account", "a", "", "User account to use. Required unless a default account is set with the 'default-account' configuration or the "+config.AccountEnv+" environment variable")
+	cmd.PersistentFlags().StringArray("values", nil, "(Optional) YAML file with the values to render templated resources with, like {{ .Values.threshold }}. Resources are only rendered when values are given. Can be repeated, later files override earlier ones")
+	cmd.PersistentFlags().StringArray("set", nil, "(Optional) Sets a value to render templated resources with, like --set threshold=90. Can be repeated and overrides the values files")
+	cmd.PersistentFlags().String("out", "", "(Optional) File to save the plan to")
+	cmd.PersistentFlags().Bool("prune", false, "(Optional) Deletes resources from the account that no longer exist in the included resource folders")
+	return cmd
+}
+
+// planCommand classifies all resources against the account, prints the plan and saves it if requested
+func planCommand(cmd *cobra.Command, args []string) {
+	printer := newPrinter()
+	account := getAccount(cmd)
+	if account == "" {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Account is required"))
+	}
+
+	if len(args) < 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Resource is required"))
+	}
+
+	prune, _ := cmd.PersistentFlags().GetBool("prune")
+	resources := loadResources(args, getValues(cmd), prune)
+	requireValidResources(resources)
+	if prune {
+		resources = append(resources, getPrunedResources(args, resources, listRemoteNames(account))...)
+	}
+	planResources(newWorkerPool(cmd), account, resources)
+	showPlan(printer, account, resources)
+
+	exitWithResults(resources)
+
+	out := cmd.PersistentFlags().Lookup("out").Value.String()
+	if out != "" {
+		if err := writePlan(out, account, resources); err != nil {
+			ExitWithError(ExitError, fmt.Errorf("Could not save plan to %s\n%s", out, err))
+		}
+		fmt.Fprintf(printer.messages(), "\nPlan saved to %s. To apply exactly these changes run:\n\toutlyer apply %s\n", out, out)
+	}
+}
+
+// writePlan persists the classified resources to the plan file
+func writePlan(path, account string, resources []resource) error {
+	plan := savedPlan{Version: planFileVersion, Account: account}
+	for _, resource := range resources {
+		plan.Resources = append(plan.Resources, plannedResource{
+			Path:        resource.path,
+			Name:        resource.getName(),
+			Action:      resource.action,
+			Fingerprint: resource.fingerprint,
+			Payload:     string(resource.bytes),
+		})
+	}
+
+	planInBytes, err := yaml.Marshal(&plan)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, planInBytes, 0600)
+}
+
+// readPlan loads a plan file. It returns false if the path is a resource or not a plan file.
+func readPlan(path string) (*savedPlan, bool) {
+	fileInfo, err := os.Stat(path)
+	if err != nil || fileInfo.IsDir() {
+		return nil, false
+	}
+	if isResourcePath(path) {
+		return nil, false
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var plan savedPlan
+	if err := yaml.Unmarshal(content, &plan); err != nil || plan.Version == 0 || plan.Account == "" {
+		return nil, false
+	}
+	if plan.Version > planFileVersion {
+		ExitWithError(ExitError, fmt.Errorf("plan %s was created by a newer version of the Outlyer CLI", path))
+	}
+	return &plan, true
+}
+
+// getPlannedResources rebuilds the resources recorded in the plan
+func getPlannedResources(plan *savedPlan) []resource {
+	resources := make([]resource, len(plan.Resources))
+	for i, planned := range plan.Resources {
+		resources[i] = resource{path: planned.Path, name: planned.Name, bytes: []byte(planned.Payload), status: "FAIL"}
+		if planned.Action == actionDelete {
+			resources[i].action = actionDelete
+		}
+	}
+	return resources
+}
+
+// verifyPlan classifies the planned resources again and checks that neither their remote state
+// nor their resulting action changed since the plan was created
+func verifyPlan(pool *workerPool, plan *savedPlan, resources []resource) []string {
+	planResources(pool, plan.Account, resources)
+
+	var outdated []string
+	for i, planned := range plan.Resources {
+		resource := resources[i]
+		if resource.err != nil {
+			outdated = append(outdated, fmt.Sprintf("%s: %s", resource.getAPIPath(), resource.err))
+		} else if resource.fingerprint != planned.Fingerprint || resource.action != planned.Action {
+			outdated = append(outdated, fmt.Sprintf("%s: changed in account '%s' since the plan was created", resource.getAPIPath(), plan.Account))
+		}
+	}
+	return outdated
+}
@@ -0,0 +1,37 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestPlanFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantOut    string
+		wantOutput string
+	}{
+		{"out", []string{"--out", "plan.out"}, "plan.out", "table"},
+		{"output shorthand", []string{"-o", "json", "--out", "plan.out"}, "plan.out", "json"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := &cobra.Command{Use: "outlyer"}
+			root.PersistentFlags().StringP("output", "o", "table", "")
+			plan := NewPlanCommand()
+			root.AddCommand(plan)
+
+			if err := plan.ParseFlags(test.args); err != nil {
+				t.Fatal(err)
+			}
+			if out, _ := plan.Flags().GetString("out"); out != test.wantOut {
+				t.Errorf("--out = %v, want %v", out, test.wantOut)
+			}
+			if output, _ := plan.Flags().GetString("output"); output != test.wantOutput {
+				t.Errorf("--output = %v, want %v", output, test.wantOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestCompareValues(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want []string
+	}{
+		{"equal", "a: 1\nb: [x, y]", "b: [x, y]\na: 1", nil},
+		{"updated field", "a: 1", "a: 2", []string{"~ f.a: 1 => 2"}},
+		{"added field", "a: 1", "a: 1\nb: text", []string{"+ f.b: \"text\""}},
+		{"remote-only field", "a: 1\nb: {c: true}", "a: 1", nil},
+		{"nested remote-only field", "obj: {a: 1, id: 7, m: {b: 1, c: 2}}", "obj: {a: 2, m: {b: 1}}", []string{"~ f.obj.a: 1 => 2"}},
+		{"remote-only field of a list item", "l: [{x: 1, y: 2}]", "l: [{x: 1}]", nil},
+		{"removed list item", "l: [1, 2]", "l: [1]", []string{"- f.l[1]: 2"}},
+		{"list item", "l: [{x: 1}, {x: 2}]", "l: [{x: 1}, {x: 3}, {x: 4}]", []string{"~ f.l[1].x: 2 => 3", "+ f.l[2]: {x: 4}"}},
+		{"type change", "a: [1]", "a: 1", []string{"~ f.a: [1] => 1"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var from, to interface{}
+			yaml.Unmarshal([]byte(test.from), &from)
+			yaml.Unmarshal([]byte(test.to), &to)
+
+			var got []string
+			for _, change := range compareValues("f", from, to) {
+				got = append(got, change.String())
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("compareValues() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWithoutRemoteOnlyFields(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{"equal", "a: 1\nb: [x, y]", "b: [x, y]\na: 1", "a: 1\nb: [x, y]"},
+		{"remote-only field", "a: 1\nb: {c: true}", "a: 2", "a: 1"},
+		{"nested remote-only field", "obj: {a: 1, id: 7, m: {b: 1, c: 2}}", "obj: {a: 2, m: {b: 1}}", "obj: {a: 1, m: {b: 1}}"},
+		{"remote-only field of a list item", "l: [{x: 1, y: 2}]", "l: [{x: 1}]", "l: [{x: 1}]"},
+		{"removed list item", "l: [1, {x: 1, y: 2}]", "l: [1]", "l: [1, {x: 1, y: 2}]"},
+		{"type change", "a: {b: 1}", "a: 1", "a: {b: 1}"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var from, to, want interface{}
+			yaml.Unmarshal([]byte(test.from), &from)
+			yaml.Unmarshal([]byte(test.to), &to)
+			yaml.Unmarshal([]byte(test.want), &want)
+
+			if got := withoutRemoteOnlyFields(from, to); !reflect.DeepEqual(got, want) {
+				t.Errorf("withoutRemoteOnlyFields() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestShowPlan(t *testing.T) {
+	resources := []resource{
+		{path: "demo/alerts/docker.yaml", name: "docker", action: actionUpdate, changes: []fieldChange{{kind: '~', path: "threshold", from: 80, to: 90}}},
+		{path: "demo/checks/redis.yaml", name: "redis", action: actionCreate},
+		{path: "demo/views/hosts.yaml", name: "hosts", err: fmt.Errorf("invalid resource")},
+	}
+
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"table", "\nResources to apply to account 'acme'...\n\n" +
+			"\t~ demo/alerts/docker.yaml (update)\n\t    ~ threshold: 80 => 90\n" +
+			"\t+ demo/checks/redis.yaml (create)\n" +
+			"\t! demo/views/hosts.yaml (invalid resource)\n" +
+			"\nPlan: 1 to create, 1 to update, 0 to delete, 0 unchanged.\n"},
+		{"jsonpath={[*].action}", "UPDATE CREATE "},
+		{"go-template={{range .}}{{.resource}} {{.changes}} {{.reason}};{{end}}", "alerts/docker [~ threshold: 80 => 90] ;checks/redis [] ;views/hosts [] invalid resource;"},
+	}
+	for _, test := range tests {
+		t.Run(test.output, func(t *testing.T) {
+			p, err := parseOutput(test.output)
+			if err != nil {
+				t.Fatalf("parseOutput() error = %v", err)
+			}
+			var out bytes.Buffer
+			p.out = &out
+			showPlan(p, "acme", resources)
+			if got := out.String(); got != test.want {
+				t.Errorf("showPlan() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// newPruneFolder creates a folder with an empty alerts folder and a checks folder with the docker check
+func newPruneFolder(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "prune")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(filepath.Join(dir, "alerts"), 0755)
+	os.MkdirAll(filepath.Join(dir, "checks"), 0755)
+	if err := ioutil.WriteFile(filepath.Join(dir, "checks", "docker.yaml"), []byte("name: docker\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// remoteNames lists the resources of the account the prune tests run against
+func remoteNames(resourceType string) []string {
+	return map[string][]string{
+		Alerts: {"alerts/docker", "alerts/disk"},
+		Checks: {"checks/docker", "checks/redis"},
+		Views:  {"views/hosts"},
+	}[resourceType]
+}
+
+func TestGetRemoteOnlyNames(t *testing.T) {
+	dir := newPruneFolder(t)
+	defer os.RemoveAll(dir)
+	resources := []resource{{path: filepath.Join(dir, "checks", "docker.yaml"), name: "docker"}}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"whole folder", []string{dir}, []string{"alerts/docker", "alerts/disk", "checks/redis"}},
+		{"empty type folder", []string{filepath.Join(dir, "alerts")}, []string{"alerts/docker", "alerts/disk"}},
+		{"type folder", []string{filepath.Join(dir, "checks")}, []string{"checks/redis"}},
+		{"single file", []string{filepath.Join(dir, "checks", "docker.yaml")}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := getRemoteOnlyNames(test.args, resources, remoteNames); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("getRemoteOnlyNames() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetPrunedResources(t *testing.T) {
+	dir := newPruneFolder(t)
+	defer os.RemoveAll(dir)
+
+	args := []string{filepath.Join(dir, "alerts")}
+	resources := loadResources(args, nil, true)
+	if len(resources) != 0 {
+		t.Fatalf("loadResources() = %v, want no resources", resources)
+	}
+
+	want := []resource{
+		{path: "alerts/docker", name: "docker", status: "FAIL", action: actionDelete},
+		{path: "alerts/disk", name: "disk", status: "FAIL", action: actionDelete},
+	}
+	if got := getPrunedResources(args, resources, remoteNames); !reflect.DeepEqual(got, want) {
+		t.Errorf("getPrunedResources() = %v, want %v", got, want)
+	}
+}
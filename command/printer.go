@@ -0,0 +1,210 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/outlyerapp/outlyer-cli/config"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Output formats supported by the global --output flag
+const (
+	outputTable      = "table"
+	outputJSON       = "json"
+	outputYAML       = "yaml"
+	outputGoTemplate = "go-template"
+	outputJSONPath   = "jsonpath"
+)
+
+// column describes a column printed in table output and the item fields it shows. Since not all
+// resources have the same fields, it shows the first one of the fields the item has.
+type column struct {
+	header string
+	fields []string
+}
+
+// getValue returns the value of the first of the column fields the item has
+func (c column) getValue(item map[string]interface{}) string {
+	if value := c.getRawValue(item); value != nil {
+		return fmt.Sprint(value)
+	}
+	return ""
+}
+
+// getRawValue returns the value of the first of the column fields the item has as it is, or nil if it has none
+func (c column) getRawValue(item map[string]interface{}) interface{} {
+	for _, field := range c.fields {
+		if value, found := item[field]; found && value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+// printer prints lists of items in the output format selected with the global --output flag,
+// so every command can be consumed both by humans and by scripts
+type printer struct {
+	format   string
+	template *template.Template
+	jsonPath *jsonPath
+	out      io.Writer
+}
+
+// newPrinter creates a printer for the output format set by the --output flag or the 'output'
+// configuration, exiting if the format or its template is invalid. It should be created before
+// making any change, so an invalid format doesn't abort a command halfway.
+func newPrinter() *printer {
+	p, err := parseOutput(config.CLI.GetString("output"))
+	if err != nil {
+		ExitWithError(ExitBadArgs, err)
+	}
+	return p
+}
+
+// parseOutput parses an output format like 'json' or 'go-template={{range .}}{{.name}}{{end}}'
+func parseOutput(output string) (*printer, error) {
+	format, argument := output, ""
+	if i := strings.Index(output, "="); i != -1 {
+		format, argument = output[:i], output[i+1:]
+	}
+
+	p := &printer{format: format, out: os.Stdout}
+	switch format {
+	case "", outputTable:
+		p.format = outputTable
+	case outputJSON, outputYAML:
+	case outputGoTemplate:
+		if argument == "" {
+			return nil, fmt.Errorf("output %s requires a template like '%s={{range .}}{{.name}}{{\"\\n\"}}{{end}}'", format, format)
+		}
+		tmpl, err := template.New("output").Parse(argument)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output template: %s", err)
+		}
+		p.template = tmpl
+	case outputJSONPath:
+		if argument == "" {
+			return nil, fmt.Errorf("output %s requires an expression like '%s={[*].name}'", format, format)
+		}
+		path, err := parseJSONPath(argument)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output JSONPath: %s", err)
+		}
+		p.jsonPath = path
+	default:
+		return nil, fmt.Errorf("unknown output format '%s'. The available formats are: table, json, yaml, go-template=<template> and jsonpath=<expression>", output)
+	}
+	return p, nil
+}
+
+// isTable reports whether the output is meant for humans. Commands print any additional
+// messages to stderr otherwise, so they don't get mixed with the machine-readable output.
+func (p *printer) isTable() bool {
+	return p.format == outputTable
+}
+
+// messages returns where commands should print messages that are not part of the printed items
+func (p *printer) messages() io.Writer {
+	if p.isTable() {
+		return os.Stdout
+	}
+	return os.Stderr
+}
+
+// print prints the items as a table with the given columns or in the selected machine-readable format
+func (p *printer) print(columns []column, items []map[string]interface{}) error {
+	if items == nil {
+		items = []map[string]interface{}{}
+	}
+
+	if !p.isTable() {
+		return p.printValue(items)
+	}
+
+	w := tabwriter.NewWriter(p.out, 0, 8, 3, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.header
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, item := range items {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cells[i] = column.getValue(item)
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	return w.Flush()
+}
+
+// printObject prints a single object in the selected machine-readable format. Commands
+// describing a single object print their own human-readable layout for table output.
+func (p *printer) printObject(object map[string]interface{}) error {
+	if p.isTable() {
+		return fmt.Errorf("table output can't print a single object")
+	}
+	return p.printValue(object)
+}
+
+// printValue prints the value in the selected machine-readable format
+func (p *printer) printValue(value interface{}) error {
+	value = toJSONValue(value)
+	switch p.format {
+	case outputJSON:
+		// Values like plan changes contain characters such as '>', which are kept as they are
+		encoder := json.NewEncoder(p.out)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case outputYAML:
+		valueInBytes, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		_, err = p.out.Write(valueInBytes)
+		return err
+	case outputGoTemplate:
+		return p.template.Execute(p.out, value)
+	case outputJSONPath:
+		return p.jsonPath.execute(p.out, value)
+	}
+	return fmt.Errorf("unknown output format '%s'", p.format)
+}
+
+// toJSONValue converts the maps decoded from YAML, which may have keys of any type,
+// to maps with string keys so they can be encoded as JSON and walked by templates
+func toJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, fieldValue := range v {
+			converted[fmt.Sprint(key)] = toJSONValue(fieldValue)
+		}
+		return converted
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, fieldValue := range v {
+			converted[key] = toJSONValue(fieldValue)
+		}
+		return converted
+	case []map[string]interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = toJSONValue(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = toJSONValue(item)
+		}
+		return converted
+	}
+	return value
+}
@@ -0,0 +1,53 @@
+package command
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrinterPrint(t *testing.T) {
+	columns := []column{{header: "NAME", fields: []string{"name"}}, {header: "TITLE", fields: []string{"title"}}}
+	items := []map[string]interface{}{
+		{"name": "acme", "title": "Acme"},
+		{"name": "demo", "tags": map[interface{}]interface{}{"env": "prod"}},
+	}
+
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"table", "NAME   TITLE\nacme   Acme\ndemo   \n"},
+		{"", "NAME   TITLE\nacme   Acme\ndemo   \n"},
+		{"json", "[\n  {\n    \"name\": \"acme\",\n    \"title\": \"Acme\"\n  },\n  {\n    \"name\": \"demo\",\n    \"tags\": {\n      \"env\": \"prod\"\n    }\n  }\n]\n"},
+		{"yaml", "- name: acme\n  title: Acme\n- name: demo\n  tags:\n    env: prod\n"},
+		{"go-template={{range .}}{{.name}} {{end}}", "acme demo "},
+		{"jsonpath={[*].name}", "acme demo"},
+		{"jsonpath={[1].tags.env}", "prod"},
+	}
+	for _, test := range tests {
+		t.Run(test.output, func(t *testing.T) {
+			p, err := parseOutput(test.output)
+			if err != nil {
+				t.Fatalf("parseOutput() error = %v", err)
+			}
+			var out bytes.Buffer
+			p.out = &out
+			if err := p.print(columns, items); err != nil {
+				t.Fatalf("print() error = %v", err)
+			}
+			if got := out.String(); got != test.want {
+				t.Errorf("print() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseOutputErrors(t *testing.T) {
+	for _, output := range []string{"xml", "go-template", "go-template={{.name", "jsonpath=", "jsonpath={..name}", "jsonpath={[a]}"} {
+		t.Run(output, func(t *testing.T) {
+			if _, err := parseOutput(output); err == nil {
+				t.Errorf("parseOutput(%q) expected an error", output)
+			}
+		})
+	}
+}
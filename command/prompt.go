@@ -0,0 +1,50 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/outlyerapp/outlyer-cli/terminal"
+	"github.com/spf13/cobra"
+)
+
+// assumeYesEnv is the environment variable that answers yes to all confirmations when set to true
+const assumeYesEnv = "OUTLYER_ASSUME_YES"
+
+// askForConfirmation prints the question to stderr and reads a [y/n] answer from stdin.
+// The question is answered automatically by the --yes flag or the OUTLYER_ASSUME_YES environment
+// variable, otherwise stdin must be a terminal so the command never hangs or skips silently in CI.
+func askForConfirmation(cmd *cobra.Command, question string) bool {
+	fmt.Fprintf(os.Stderr, "\n%s [y/n] ", question)
+
+	yes, _ := cmd.PersistentFlags().GetBool("yes")
+	if yes || assumeYes() {
+		fmt.Fprintln(os.Stderr, "y")
+		return true
+	}
+
+	if !terminal.IsTerminal(os.Stdin) {
+		fmt.Fprintln(os.Stderr, "")
+		ExitWithError(ExitError, fmt.Errorf("cannot ask for confirmation since stdin is not a terminal. Use --yes or set %s=true to confirm", assumeYesEnv))
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	confirmation, _ := reader.ReadString('\n')
+	confirmation = strings.Replace(confirmation, "\n", "", -1) // removes return character on *unix and darwin
+	confirmation = strings.Replace(confirmation, "\r", "", -1) // removes return character on windows
+
+	return confirmation == "y" || confirmation == "Y"
+}
+
+// assumeYes checks whether the OUTLYER_ASSUME_YES environment variable is set to true
+func assumeYes() bool {
+	value := strings.ToLower(os.Getenv(assumeYesEnv))
+	if value == "y" || value == "yes" {
+		return true
+	}
+	yes, _ := strconv.ParseBool(value)
+	return yes
+}
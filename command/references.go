@@ -0,0 +1,217 @@
+package command
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/outlyerapp/outlyer-cli/api"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// referenceKeys maps the fields that may reference other resources to the referenced resource type
+var referenceKeys = map[string]string{
+	"alert":      Alerts,
+	"alerts":     Alerts,
+	"check":      Checks,
+	"checks":     Checks,
+	"check_name": Checks,
+	"dashboard":  Dashboards,
+	"dashboards": Dashboards,
+	"plugin":     Plugins,
+	"plugins":    Plugins,
+	"view":       Views,
+	"views":      Views,
+	"view_name":  Views,
+}
+
+// pluginExtensions lists the file extensions of scripts that checks may run as plugins
+var pluginExtensions = map[string]bool{
+	".bat": true,
+	".js":  true,
+	".php": true,
+	".pl":  true,
+	".ps1": true,
+	".py":  true,
+	".rb":  true,
+	".sh":  true,
+}
+
+// extractReferences walks a resource definition looking for fields that reference other resources,
+// like an alert evaluating a check or a check running a plugin, and returns them sorted like 'checks/docker'
+func extractReferences(definition map[interface{}]interface{}) []string {
+	found := make(map[string]bool)
+	walkReferences(definition, "", found)
+
+	references := make([]string, 0, len(found))
+	for reference := range found {
+		references = append(references, reference)
+	}
+	sort.Strings(references)
+	return references
+}
+
+func walkReferences(value interface{}, key string, found map[string]bool) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		for fieldKey, fieldValue := range v {
+			walkReferences(fieldValue, strings.ToLower(fmt.Sprint(fieldKey)), found)
+		}
+	case []interface{}:
+		for _, item := range v {
+			walkReferences(item, key, found)
+		}
+	case string:
+		if v == "" {
+			return
+		}
+		if resourceType, ok := referenceKeys[key]; ok {
+			found[resourceType+"/"+v] = true
+		}
+		if key == "command" { // Checks reference the plugins they run in their command line
+			for _, arg := range strings.Fields(v) {
+				if pluginExtensions[path.Ext(arg)] {
+					found[Plugins+"/"+path.Base(arg)] = true
+				}
+			}
+		}
+	}
+}
+
+// renameReferences returns the value with the references to the renamed resources, keyed like 'checks/docker',
+// replaced by their new names. It walks the value like extractReferences, so the same fields are renamed.
+func renameReferences(value interface{}, key string, renamed map[string]string) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		for fieldKey, fieldValue := range v {
+			v[fieldKey] = renameReferences(fieldValue, strings.ToLower(fmt.Sprint(fieldKey)), renamed)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = renameReferences(item, key, renamed)
+		}
+	case string:
+		if resourceType, ok := referenceKeys[key]; ok {
+			if newName, found := renamed[resourceType+"/"+v]; found {
+				return newName
+			}
+		}
+		if key == "command" {
+			args := strings.Fields(v)
+			changed := false
+			for i, arg := range args {
+				if newName, found := renamed[Plugins+"/"+path.Base(arg)]; found && pluginExtensions[path.Ext(arg)] {
+					args[i] = path.Join(path.Dir(arg), newName)
+					changed = true
+				}
+			}
+			if changed { // Otherwise keeps the command as it is, including its spacing
+				return strings.Join(args, " ")
+			}
+		}
+	}
+	return value
+}
+
+// fetchDefinitions fetches the export view of all resources of the given type from the user account
+func fetchDefinitions(account, resourceType string) ([]map[interface{}]interface{}, error) {
+	resp, err := api.Get(accountPath(account, resourceType) + "?view=export")
+	if err != nil {
+		return nil, err
+	}
+
+	var definitions []map[interface{}]interface{}
+	if err := yaml.Unmarshal(resp, &definitions); err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+// fetchAllDefinitions fetches the export view of all resources in the account that may reference
+// other resources, keyed by their name prefixed by the resource type like 'checks/docker'
+func fetchAllDefinitions(account string) (map[string]map[interface{}]interface{}, error) {
+	allDefinitions := make(map[string]map[interface{}]interface{})
+	for _, resourceType := range resourceTypes {
+		if resourceType == Plugins { // Plugins are scripts and do not reference other resources
+			continue
+		}
+		definitions, err := fetchDefinitions(account, resourceType)
+		if err != nil {
+			return nil, err
+		}
+		for _, definition := range definitions {
+			allDefinitions[resourceType+"/"+fmt.Sprint(definition["name"])] = definition
+		}
+	}
+	return allDefinitions, nil
+}
+
+// findDependents scans all resources in the account and returns, for each resource referencing
+// any of the targets, the targets it references. Targets themselves are not reported.
+func findDependents(account string, targets []string) (map[string][]string, error) {
+	definitions, err := fetchAllDefinitions(account)
+	if err != nil {
+		return nil, err
+	}
+	return getDependents(definitions, targets), nil
+}
+
+// getDependents returns, for each of the definitions referencing any of the targets, the targets it references
+func getDependents(definitions map[string]map[interface{}]interface{}, targets []string) map[string][]string {
+	isTarget := make(map[string]bool)
+	for _, target := range targets {
+		isTarget[target] = true
+	}
+
+	dependents := make(map[string][]string)
+	for name, definition := range definitions {
+		if isTarget[name] {
+			continue
+		}
+		for _, reference := range extractReferences(definition) {
+			if isTarget[reference] {
+				dependents[name] = append(dependents[name], reference)
+			}
+		}
+	}
+	return dependents
+}
+
+// metricKeys lists the fields that name the metrics a resource evaluates or shows
+var metricKeys = map[string]bool{
+	"metric":      true,
+	"metrics":     true,
+	"metric_name": true,
+}
+
+// extractMetrics walks a resource definition looking for the metrics it evaluates or shows,
+// like the metric of an alert criteria or of a dashboard widget, and returns them sorted
+func extractMetrics(definition map[interface{}]interface{}) []string {
+	found := make(map[string]bool)
+	walkMetrics(definition, "", found)
+
+	metrics := make([]string, 0, len(found))
+	for metric := range found {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+	return metrics
+}
+
+func walkMetrics(value interface{}, key string, found map[string]bool) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		for fieldKey, fieldValue := range v {
+			walkMetrics(fieldValue, strings.ToLower(fmt.Sprint(fieldKey)), found)
+		}
+	case []interface{}:
+		for _, item := range v {
+			walkMetrics(item, key, found)
+		}
+	case string:
+		if v != "" && metricKeys[key] {
+			found[v] = true
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestExtractReferences(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		want       []string
+	}{
+		{"no references", "name: docker\ndescription: check", []string{}},
+		{"alert criteria", "name: docker\ncriteria:\n- check: docker\n- Check: kafka", []string{"checks/docker", "checks/kafka"}},
+		{"check command", "name: docker\ncommand: /usr/bin/python docker.py --host 10.0.0.1", []string{"plugins/docker.py"}},
+		{"plugin field", "name: docker\nplugin: docker.py", []string{"plugins/docker.py"}},
+		{"dashboard views", "name: docker\nviews: [containers, hosts]", []string{"views/containers", "views/hosts"}},
+		{"nested widgets", "name: docker\nwidgets:\n- view: containers\n  check: docker", []string{"checks/docker", "views/containers"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			definition := make(map[interface{}]interface{})
+			yaml.Unmarshal([]byte(test.definition), &definition)
+			if got := extractReferences(definition); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("extractReferences() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestExtractMetrics(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		want       []string
+	}{
+		{"no metrics", "name: docker\ndescription: check", []string{}},
+		{"alert criteria", "name: docker\ncriteria:\n- metric: docker.cpu\n- Metric: docker.mem", []string{"docker.cpu", "docker.mem"}},
+		{"widget metrics", "name: docker\nwidgets:\n- metrics: [docker.cpu, host.cpu]\n- metric_name: docker.cpu", []string{"docker.cpu", "host.cpu"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			definition := make(map[interface{}]interface{})
+			yaml.Unmarshal([]byte(test.definition), &definition)
+			if got := extractMetrics(definition); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("extractMetrics() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
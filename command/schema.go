@@ -0,0 +1,212 @@
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of values a schema accepts
+const (
+	kindString    = "string"
+	kindBoolean   = "boolean"
+	kindObject    = "object"
+	kindList      = "list"
+	kindDuration  = "duration"
+	kindThreshold = "threshold"
+)
+
+// schema describes the values a resource field accepts. Fields not described by the schema of an
+// object are accepted as they are, since the Outlyer API may add fields that the CLI doesn't know about.
+type schema struct {
+	kind     string
+	required bool
+	enum     []string
+	fields   map[string]schema
+	items    *schema
+}
+
+// thresholdPattern matches thresholds like '90', '> 90', '<= 0.5' or '80%'
+var thresholdPattern = regexp.MustCompile(`^(>=|<=|==|!=|>|<)?\s*-?[0-9]+(\.[0-9]+)?\s*%?$`)
+
+var (
+	severities = []string{"critical", "warning", "info"}
+	operators  = []string{">", ">=", "<", "<=", "==", "!="}
+)
+
+// resourceSchemas are the schemas of the resource types defined as YAML. Plugins are scripts and have no schema.
+var resourceSchemas = map[string]schema{
+	Alerts: {kind: kindObject, fields: map[string]schema{
+		"name":        {kind: kindString, required: true},
+		"description": {kind: kindString},
+		"enabled":     {kind: kindBoolean},
+		"severity":    {kind: kindString, enum: severities},
+		"criteria": {kind: kindList, items: &schema{kind: kindObject, fields: map[string]schema{
+			"name":      {kind: kindString},
+			"check":     {kind: kindString},
+			"metric":    {kind: kindString},
+			"operator":  {kind: kindString, enum: operators},
+			"threshold": {kind: kindThreshold},
+			"severity":  {kind: kindString, enum: severities},
+			"duration":  {kind: kindDuration},
+		}}},
+		"notifications": {kind: kindList, items: &schema{kind: kindString}},
+	}},
+	Checks: {kind: kindObject, fields: map[string]schema{
+		"name":        {kind: kindString, required: true},
+		"description": {kind: kindString},
+		"command":     {kind: kindString},
+		"interval":    {kind: kindDuration},
+		"timeout":     {kind: kindDuration},
+		"enabled":     {kind: kindBoolean},
+		"selector":    {kind: kindString},
+		"env":         {kind: kindObject},
+	}},
+	Dashboards: {kind: kindObject, fields: map[string]schema{
+		"name":        {kind: kindString, required: true},
+		"title":       {kind: kindString},
+		"description": {kind: kindString},
+		"views":       {kind: kindList, items: &schema{kind: kindString}},
+		"widgets": {kind: kindList, items: &schema{kind: kindObject, fields: map[string]schema{
+			"title":  {kind: kindString},
+			"metric": {kind: kindString},
+			"view":   {kind: kindString},
+			"check":  {kind: kindString},
+		}}},
+	}},
+	Views: {kind: kindObject, fields: map[string]schema{
+		"name":        {kind: kindString, required: true},
+		"title":       {kind: kindString},
+		"description": {kind: kindString},
+		"selector":    {kind: kindString},
+	}},
+}
+
+// schemaError is a value that doesn't match its schema, at a path like ['criteria', 0, 'threshold']
+type schemaError struct {
+	path    []interface{}
+	message string
+}
+
+// validateValue checks the value against the schema, returning an error for every field that doesn't match it
+func validateValue(s schema, value interface{}, path []interface{}) []schemaError {
+	fail := func(format string, args ...interface{}) []schemaError {
+		return []schemaError{{path: path, message: fmt.Sprintf("%s: %s", formatSchemaPath(path), fmt.Sprintf(format, args...))}}
+	}
+
+	switch s.kind {
+	case kindString:
+		text, ok := value.(string)
+		if !ok {
+			return fail("must be a string, not %s", formatValue(value))
+		}
+		if len(s.enum) > 0 && !containsString(s.enum, text) {
+			return fail("must be one of %s, not '%s'", strings.Join(s.enum, ", "), text)
+		}
+	case kindBoolean:
+		if _, ok := value.(bool); !ok {
+			return fail("must be true or false, not %s", formatValue(value))
+		}
+	case kindDuration:
+		switch v := value.(type) {
+		case int:
+			if v <= 0 {
+				return fail("must be a positive number of seconds, not %d", v)
+			}
+		case string:
+			if duration, err := time.ParseDuration(v); err != nil || duration <= 0 {
+				return fail("must be a number of seconds or a duration like '30s' or '5m', not '%s'", v)
+			}
+		default:
+			return fail("must be a number of seconds or a duration like '30s' or '5m', not %s", formatValue(value))
+		}
+	case kindThreshold:
+		switch v := value.(type) {
+		case int, float64:
+		case string:
+			if !thresholdPattern.MatchString(strings.TrimSpace(v)) {
+				return fail("must be a number optionally preceded by a comparison and followed by %%, like '> 90' or '80%%', not '%s'", v)
+			}
+		default:
+			return fail("must be a number optionally preceded by a comparison and followed by %%, like '> 90' or '80%%', not %s", formatValue(value))
+		}
+	case kindList:
+		items, ok := value.([]interface{})
+		if !ok {
+			return fail("must be a list, not %s", formatValue(value))
+		}
+		if s.items == nil {
+			return nil
+		}
+		var errs []schemaError
+		for i, item := range items {
+			errs = append(errs, validateValue(*s.items, item, appendPath(path, i))...)
+		}
+		return errs
+	case kindObject:
+		object, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return fail("must be an object with fields, not %s", formatValue(value))
+		}
+		var errs []schemaError
+		for _, field := range sortedSchemaFields(s.fields) {
+			fieldValue, found := object[field]
+			if !found || fieldValue == nil {
+				if s.fields[field].required {
+					errs = append(errs, schemaError{path: path, message: fmt.Sprintf("%s: is required", formatSchemaPath(appendPath(path, field)))})
+				}
+				continue
+			}
+			errs = append(errs, validateValue(s.fields[field], fieldValue, appendPath(path, field))...)
+		}
+		return errs
+	}
+	return nil
+}
+
+// appendPath returns a copy of the path with the key or index appended, so sibling paths don't share their backing array
+func appendPath(path []interface{}, element interface{}) []interface{} {
+	appended := make([]interface{}, len(path), len(path)+1)
+	copy(appended, path)
+	return append(appended, element)
+}
+
+// formatSchemaPath formats a path like 'criteria[0].threshold'. The empty path is the whole resource.
+func formatSchemaPath(path []interface{}) string {
+	if len(path) == 0 {
+		return "resource"
+	}
+	var formatted string
+	for _, element := range path {
+		if i, ok := element.(int); ok {
+			formatted += fmt.Sprintf("[%d]", i)
+		} else if formatted == "" {
+			formatted = fmt.Sprint(element)
+		} else {
+			formatted += "." + fmt.Sprint(element)
+		}
+	}
+	return formatted
+}
+
+// sortedSchemaFields returns the fields of the schema sorted, so errors are reported in a stable order
+func sortedSchemaFields(fields map[string]schema) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// containsString checks whether the list contains the text
+func containsString(list []string, text string) bool {
+	for _, item := range list {
+		if item == text {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/outlyerapp/outlyer-cli/config"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// templateData is the data resource templates are rendered with, so values are referenced like {{ .Values.threshold }}
+type templateData struct {
+	Values map[interface{}]interface{}
+}
+
+// templateFuncs are the functions available to resource templates besides the text/template builtins
+var templateFuncs = template.FuncMap{
+	"env":      os.Getenv,
+	"get":      getValue,
+	"default":  defaultValue,
+	"required": requiredValue,
+}
+
+// getValue returns the value of a dotted key like 'notify.channel', or nil when it is not set, so values
+// that may be missing are given a default like in {{ get .Values "notify.channel" | default "ops" }}.
+// Referencing a missing value directly, like {{ .Values.notify.channel }}, fails rendering instead.
+func getValue(values map[interface{}]interface{}, key string) interface{} {
+	value, _ := config.GetValue(values, key)
+	return value
+}
+
+// defaultValue returns the default when the value is not set or empty, like in {{ get .Values "threshold" | default 80 }}.
+// Zero and false are kept, since they are valid thresholds and settings.
+func defaultValue(defaultValue, value interface{}) interface{} {
+	if value == nil || value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// requiredValue fails rendering with the message when the value is not set or empty,
+// like in {{ required "the notification channel is required" (get .Values "channel") }}
+func requiredValue(message string, value interface{}) (interface{}, error) {
+	if value == nil || value == "" {
+		return nil, fmt.Errorf("%s", message)
+	}
+	return value, nil
+}
+
+// getValues reads the values to render resource templates with from the --values files and the --set
+// flags, exiting if any of them is invalid. Rendering is opt-in: without values it returns nil and the
+// resource files are read as they are, so files with literal braces, like '{{host}}' legends, still apply.
+func getValues(cmd *cobra.Command) map[interface{}]interface{} {
+	files, _ := cmd.PersistentFlags().GetStringArray("values")
+	sets, _ := cmd.PersistentFlags().GetStringArray("set")
+	if len(files) == 0 && len(sets) == 0 {
+		return nil
+	}
+	values, err := loadValues(files, sets)
+	if err != nil {
+		ExitWithError(ExitBadArgs, err)
+	}
+	return values
+}
+
+// loadValues merges the values of the files, later files overriding earlier ones, and then
+// sets the 'key=value' pairs over them. Values are parsed as YAML, so 'threshold=90' sets a number.
+func loadValues(files, sets []string) (map[interface{}]interface{}, error) {
+	values := make(map[interface{}]interface{})
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read values file\n%s", err)
+		}
+		fileValues := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(content, &fileValues); err != nil {
+			return nil, fmt.Errorf("%s: invalid values file\n%s", file, err)
+		}
+		mergeValues(values, fileValues)
+	}
+
+	for _, set := range sets {
+		i := strings.Index(set, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("%s: values must be set like 'key=value' or 'section.key=value'", set)
+		}
+		var value interface{}
+		if err := yaml.Unmarshal([]byte(set[i+1:]), &value); err != nil {
+			value = set[i+1:]
+		}
+		if value == nil {
+			value = set[i+1:]
+		}
+		config.SetValue(values, set[:i], value)
+	}
+	return values, nil
+}
+
+// mergeValues sets the values of src into dst, merging the sections both of them have
+func mergeValues(dst, src map[interface{}]interface{}) {
+	for key, value := range src {
+		srcSection, srcIsSection := value.(map[interface{}]interface{})
+		dstSection, dstIsSection := dst[key].(map[interface{}]interface{})
+		if srcIsSection && dstIsSection {
+			mergeValues(dstSection, srcSection)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// renderResource renders the resource file as a template with the values. Errors tell the file and line
+// that failed, including the lines using values that are not set.
+func renderResource(path string, content []byte, values map[interface{}]interface{}) ([]byte, error) {
+	tmpl, err := template.New(path).Funcs(templateFuncs).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, templateData{Values: values}); err != nil {
+		if strings.Contains(err.Error(), "map has no entry for key") {
+			return nil, fmt.Errorf("%s\nset the value with --values or --set, or look it up with get to give it a default", err)
+		}
+		return nil, err
+	}
+	return rendered.Bytes(), nil
+}
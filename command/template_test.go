@@ -0,0 +1,117 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderResource(t *testing.T) {
+	os.Setenv("OUTLYER_TEST_HOST", "web-1")
+	defer os.Unsetenv("OUTLYER_TEST_HOST")
+
+	values := map[interface{}]interface{}{
+		"threshold": 90,
+		"disabled":  false,
+		"notify":    map[interface{}]interface{}{"channel": "ops"},
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{"untemplated", "name: docker\nwarning: 80\n", "name: docker\nwarning: 80\n", ""},
+		{"values", "warning: {{ .Values.threshold }}\nchannel: {{ .Values.notify.channel }}\n", "warning: 90\nchannel: ops\n", ""},
+		{"env", "host: {{ env \"OUTLYER_TEST_HOST\" }}\n", "host: web-1\n", ""},
+		{"default", "critical: {{ get .Values \"critical\" | default 95 }}\nenabled: {{ get .Values \"disabled\" | default true }}\n", "critical: 95\nenabled: false\n", ""},
+		{"nested default", "channel: {{ default \"ops\" (get .Values \"pager.channel\") }}\nemail: {{ get .Values \"notify.email\" | default \"ops@example.com\" }}\n", "channel: ops\nemail: ops@example.com\n", ""},
+		{"default in condition", "{{ if get .Values \"paused\" | default false }}paused: true{{ end }}\n", "\n", ""},
+		{"get", "channel: {{ get .Values \"notify.channel\" }}\n", "channel: ops\n", ""},
+		{"missing value", "name: docker\ncritical: {{ .Values.critical }}\n", "", "alerts/docker.yaml:2:20: executing \"alerts/docker.yaml\" at <.Values.critical>: map has no entry for key \"critical\""},
+		{"missing section", "channel: {{ .Values.pager.channel }}\n", "", "map has no entry for key \"pager\""},
+		{"missing value next to a default", "warning: {{ get .Values \"critical\" | default 95 }}\ncritical: {{ .Values.critical }}\n", "", "map has no entry for key \"critical\""},
+		{"no value text", "description: <no value>\n", "description: <no value>\n", ""},
+		{"required", "channel: {{ get .Values \"channel\" | required \"channel is required\" }}\n", "", "channel is required"},
+		{"parse error", "warning: {{ .Values.threshold\n", "", "alerts/docker.yaml:1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := renderResource("alerts/docker.yaml", []byte(test.content), values)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("renderResource() error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil || string(got) != test.want {
+				t.Errorf("renderResource() = %q, %v, want %q", got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestRenderResourceKeepsValues(t *testing.T) {
+	values := map[interface{}]interface{}{"notify": map[interface{}]interface{}{"email": "dev@example.com"}}
+	if _, err := renderResource("alerts/docker.yaml", []byte("channel: {{ get .Values \"notify.channel\" | default \"ops\" }}\n"), values); err != nil {
+		t.Fatal(err)
+	}
+	want := map[interface{}]interface{}{"notify": map[interface{}]interface{}{"email": "dev@example.com"}}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("renderResource() changed the values to %v, want %v", values, want)
+	}
+}
+
+func TestGetResourcesWithoutValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resources")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content := "name: docker\ndescription: <no value>\nwidgets:\n- legend: '{{host}}'\n"
+	path := filepath.Join(dir, "dashboards", "docker.yaml")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	resources := getResources([]string{path}, nil)
+	if string(resources[0].bytes) != content || resources[0].getName() != "docker" {
+		t.Errorf("getResources() = %q named %q, want %q named docker", resources[0].bytes, resources[0].getName(), content)
+	}
+}
+
+func TestLoadValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "values")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	common := filepath.Join(dir, "common.yaml")
+	prod := filepath.Join(dir, "prod.yaml")
+	ioutil.WriteFile(common, []byte("threshold: 80\nnotify:\n  channel: dev\n  email: dev@example.com\n"), 0644)
+	ioutil.WriteFile(prod, []byte("notify:\n  channel: ops\n"), 0644)
+
+	values, err := loadValues([]string{common, prod}, []string{"threshold=90", "notify.pager=true", "host=web-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[interface{}]interface{}{
+		"threshold": 90,
+		"host":      "web-1",
+		"notify":    map[interface{}]interface{}{"channel": "ops", "email": "dev@example.com", "pager": true},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("loadValues() = %v, want %v", values, want)
+	}
+
+	if _, err := loadValues(nil, []string{"threshold"}); err == nil {
+		t.Errorf("loadValues() accepted a value without key=value")
+	}
+}
@@ -0,0 +1,255 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// yamlErrorLine matches the line number in the errors of the YAML parser, like 'yaml: line 3: found character that cannot start any token'
+var yamlErrorLine = regexp.MustCompile(`^yaml: line ([0-9]+): `)
+
+// validationError is a problem found in a resource file, at the given line or at an unknown line if 0.
+// Blocking problems, like invalid YAML or a missing name, prevent the resource from being applied at all,
+// while the rest only tell that the resource doesn't match the schema the CLI knows of its type.
+type validationError struct {
+	path     string
+	line     int
+	message  string
+	blocking bool
+}
+
+func (e validationError) String() string {
+	if e.line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.path, e.line, e.message)
+	}
+	return fmt.Sprintf("%s: %s", e.path, e.message)
+}
+
+// validationColumns are the columns of the table listing the problems found in resource files
+var validationColumns = []column{
+	{header: "FILE", fields: []string{"file"}},
+	{header: "LINE", fields: []string{"line"}},
+	{header: "ERROR", fields: []string{"error"}},
+}
+
+// NewValidateCommand creates a Command for validating resource files without accessing the Outlyer API
+func NewValidateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate .|[folder]|[file]",
+		Short: "Checks that resource files are valid YAML with the fields each resource type requires, without accessing the Outlyer API. The available resources are: alerts, checks, dashboards, plugins and views",
+		Example: `
+Validates all resources from inside the 'demo' directory:
+$ outlyer validate .
+
+Validates only alerts and checks, rendering their templated values with the production values:
+$ outlyer validate path_to/demo/alerts path_to/demo/checks --values=values/prod.yaml
+
+Validates the effective resources of an overlay:
+$ outlyer validate path_to/overlays/prod`,
+		Run: validateCommand,
+	}
+
+	cmd.PersistentFlags().StringArray("values", nil, "(Optional) YAML file with the values to render templated resources with, like {{ .Values.threshold }}. Resources are only rendered when values are given. Can be repeated, later files override earlier ones")
+	cmd.PersistentFlags().StringArray("set", nil, "(Optional) Sets a value to render templated resources with, like --set threshold=90. Can be repeated and overrides the values files")
+	return cmd
+}
+
+// validateCommand validates every resource file and lists the problems found, failing if there are any
+func validateCommand(cmd *cobra.Command, args []string) {
+	printer := newPrinter()
+	if len(args) < 1 {
+		ExitWithError(ExitBadArgs, fmt.Errorf("Resource is required"))
+	}
+
+	resources := loadResources(args, getValues(cmd), false)
+	errs := validateResources(resources)
+
+	items := make([]map[string]interface{}, len(errs))
+	for i, err := range errs {
+		items[i] = map[string]interface{}{"file": err.path, "line": "", "error": err.message}
+		if err.line > 0 {
+			items[i]["line"] = err.line
+		}
+	}
+	if printer.isTable() {
+		for _, err := range errs {
+			fmt.Println(err)
+		}
+	} else if err := printer.print(validationColumns, items); err != nil {
+		ExitWithError(ExitError, fmt.Errorf("Could not print validation errors\n%s", err))
+	}
+
+	if len(errs) > 0 {
+		ExitWithError(ExitError, fmt.Errorf("%d errors found in %d resources", len(errs), len(resources)))
+	}
+	fmt.Fprintf(printer.messages(), "Validated %d resources, no errors found\n", len(resources))
+}
+
+// validateResources checks every resource against the schema of its type
+func validateResources(resources []resource) []validationError {
+	var errs []validationError
+	for _, resource := range resources {
+		errs = append(errs, validateResource(resource)...)
+	}
+	return errs
+}
+
+// requireValidResources exits listing the blocking problems found in the resources before anything is applied.
+// Other problems are printed as warnings, since the schemas may lag behind what the Outlyer API accepts.
+func requireValidResources(resources []resource) {
+	var messages []string
+	for _, err := range validateResources(resources) {
+		if err.blocking {
+			messages = append(messages, err.String())
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+		}
+	}
+	if len(messages) > 0 {
+		ExitWithError(ExitError, fmt.Errorf("invalid resources, fix them and try again. Nothing was applied\n\t- %s", strings.Join(messages, "\n\t- ")))
+	}
+}
+
+// validateResource parses the resource and checks it against the schema of its type,
+// locating each problem in the file
+func validateResource(res resource) []validationError {
+	resourceSchema, found := resourceSchemas[res.getType()]
+	if !found {
+		return nil
+	}
+
+	var definition interface{}
+	if err := yaml.Unmarshal(res.bytes, &definition); err != nil {
+		message := err.Error()
+		line := 0
+		if match := yamlErrorLine.FindStringSubmatch(message); match != nil {
+			line, _ = strconv.Atoi(match[1])
+			message = message[len(match[0]):]
+		}
+		return []validationError{{path: res.path, line: line, message: "invalid YAML: " + strings.TrimPrefix(message, "yaml: "), blocking: true}}
+	}
+	if definition == nil {
+		return []validationError{{path: res.path, message: "the file is empty", blocking: true}}
+	}
+
+	var errs []validationError
+	lines := splitLines(string(res.bytes))
+	for _, err := range validateValue(resourceSchema, definition, nil) {
+		// Errors of the whole resource, like not being an object or missing its required name, and of the name
+		// itself are blocking, since the resource can't be identified in the account without a valid name
+		blocking := len(err.path) == 0 || err.path[0] == "name"
+		errs = append(errs, validationError{path: res.path, line: findLine(lines, err.path), message: err.message, blocking: blocking})
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].line < errs[j].line })
+	return errs
+}
+
+// findLine returns the line of the field at the path, like ['criteria', 0, 'threshold'], in the YAML lines.
+// Since the YAML parser doesn't report where values are, it follows the indentation of block YAML,
+// returning the line of the deepest parent found for fields in flow style like '{threshold: 90}'.
+func findLine(lines []string, path []interface{}) int {
+	start, end, line := 0, len(lines), 0
+	for _, element := range path {
+		var found int
+		if index, isIndex := element.(int); isIndex {
+			found = findListItem(lines, start, end, index)
+		} else {
+			found = findKey(lines, start, end, fmt.Sprint(element), line > 0)
+		}
+		if found == -1 {
+			break
+		}
+		line = found + 1
+		start, end = found, findBlockEnd(lines, found, end)
+	}
+	return line
+}
+
+// findKey returns the index of the line with the key among the first level keys of the block, or -1.
+// Blocks of nested fields start with the key of their parent, while blocks of list items usually start with their first key.
+func findKey(lines []string, start, end int, key string, nested bool) int {
+	level := -1
+	for i := start; i < end; i++ {
+		indent, text := getLineIndent(lines[i], i == start)
+		if text == "" || (nested && i == start && !strings.HasPrefix(strings.TrimLeft(lines[i], " "), "- ")) {
+			continue
+		}
+		if level == -1 {
+			level = indent
+		}
+		if indent != level {
+			continue
+		}
+		for _, candidate := range []string{key, `"` + key + `"`, `'` + key + `'`} {
+			if strings.HasPrefix(text, candidate+":") {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// findListItem returns the index of the line starting the item of the first level list of the block, or -1
+func findListItem(lines []string, start, end, index int) int {
+	level, count := -1, 0
+	for i := start; i < end; i++ {
+		indent, text := getLineIndent(lines[i], false)
+		if text == "" {
+			continue
+		}
+		if i == start && !strings.HasPrefix(text, "-") {
+			// The block starts with the key of the list, so its items are on the next lines
+			continue
+		}
+		if level == -1 {
+			level = indent
+		}
+		if indent == level && (text == "-" || strings.HasPrefix(text, "- ")) {
+			if count == index {
+				return i
+			}
+			count++
+		}
+	}
+	return -1
+}
+
+// findBlockEnd returns the index of the line after the block starting at the given line,
+// which includes the following lines indented more deeply and the list items of its key
+func findBlockEnd(lines []string, start, end int) int {
+	level, text := getLineIndent(lines[start], false)
+	isListItem := strings.HasPrefix(text, "-")
+	for i := start + 1; i < end; i++ {
+		indent, text := getLineIndent(lines[i], false)
+		if text == "" {
+			continue
+		}
+		if indent < level || (indent == level && (isListItem || !strings.HasPrefix(text, "-"))) {
+			return i
+		}
+	}
+	return end
+}
+
+// getLineIndent returns the indentation of the line and its text, or an empty text for blank lines
+// and comments. Within list items, the indentation of the text after the '- ' markers is returned.
+func getLineIndent(line string, skipListMarkers bool) (int, string) {
+	text := strings.TrimLeft(line, " ")
+	indent := len(line) - len(text)
+	if text == "" || strings.HasPrefix(text, "#") || text == "---" {
+		return 0, ""
+	}
+	for skipListMarkers && strings.HasPrefix(text, "- ") {
+		trimmed := strings.TrimLeft(text[2:], " ")
+		indent += len(text) - len(trimmed)
+		text = trimmed
+	}
+	return indent, text
+}
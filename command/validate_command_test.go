@@ -0,0 +1,136 @@
+package command
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestValidateResource(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    []string
+	}{
+		{"valid alert", "alerts/docker.yaml", "name: docker\nseverity: critical\ncriteria:\n- check: docker\n  threshold: '> 90'\n", nil},
+		{"valid check with unknown fields", "checks/docker.yaml", "name: docker\ninterval: 30s\nid: 12\n", nil},
+		{"plugins are not validated", "plugins/docker.py", "print('hi')", nil},
+		{"invalid YAML", "checks/docker.yaml", "name: docker\ncommand: [x\n", []string{"checks/docker.yaml:2: invalid YAML: did not find expected ',' or ']'"}},
+		{"empty", "views/docker.yaml", "# nothing yet\n", []string{"views/docker.yaml: the file is empty"}},
+		{"not an object", "views/docker.yaml", "- docker\n", []string{"views/docker.yaml: resource: must be an object with fields, not [\"docker\"]"}},
+		{"missing name", "views/docker.yaml", "title: Docker\n", []string{"views/docker.yaml: name: is required"}},
+		{"invalid fields", "alerts/docker.yaml",
+			"name: docker\n# thresholds\ncriteria:\n- check: docker\n  threshold: 90\n- check: kafka\n\n  threshold: lots\nseverity: urgent\n",
+			[]string{
+				"alerts/docker.yaml:8: criteria[1].threshold: must be a number optionally preceded by a comparison and followed by %, like '> 90' or '80%', not 'lots'",
+				"alerts/docker.yaml:9: severity: must be one of critical, warning, info, not 'urgent'",
+			}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, err := range validateResource(resource{path: test.path, bytes: []byte(test.content)}) {
+				got = append(got, err.String())
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("validateResource() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFindLine(t *testing.T) {
+	lines := splitLines(`name: docker
+notify:
+  channel: ops
+  "email": ops@example.com
+criteria:
+  - check: docker
+    threshold: 90
+  -
+    check: kafka
+    threshold: 80
+views: [containers, hosts]
+severity: critical`)
+
+	tests := []struct {
+		path []interface{}
+		want int
+	}{
+		{[]interface{}{"name"}, 1},
+		{[]interface{}{"notify", "channel"}, 3},
+		{[]interface{}{"notify", "email"}, 4},
+		{[]interface{}{"criteria", 0, "check"}, 6},
+		{[]interface{}{"criteria", 0, "threshold"}, 7},
+		{[]interface{}{"criteria", 1, "threshold"}, 10},
+		{[]interface{}{"views", 1}, 11},
+		{[]interface{}{"severity"}, 12},
+		{[]interface{}{"missing"}, 0},
+		{nil, 0},
+	}
+	for _, test := range tests {
+		if got := findLine(lines, test.path); got != test.want {
+			t.Errorf("findLine(%v) = %d, want %d", test.path, got, test.want)
+		}
+	}
+}
+
+func TestValidateResourceBlocking(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    []bool
+	}{
+		{"invalid YAML", "checks/docker.yaml", "name: [docker\n", []bool{true}},
+		{"empty", "views/docker.yaml", "\n", []bool{true}},
+		{"not an object", "views/docker.yaml", "- docker\n", []bool{true}},
+		{"missing name", "views/docker.yaml", "title: Docker\n", []bool{true}},
+		{"invalid name", "views/docker.yaml", "name: [docker]\n", []bool{true}},
+		{"fields unlike the schema", "alerts/docker.yaml", "name: docker\nseverity: urgent\nnotifications:\n- type: email\n", []bool{false, false}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []bool
+			for _, err := range validateResource(resource{path: test.path, bytes: []byte(test.content)}) {
+				got = append(got, err.blocking)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("validateResource() blocking = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateCanonicalExport(t *testing.T) {
+	definitions := map[string]map[interface{}]interface{}{
+		Alerts: {
+			"id": 12, "name": "docker", "description": "Docker containers\r\nin production ", "enabled": true, "severity": "critical",
+			"created_at": "2018-05-01T10:00:00Z", "updated_by": "jane",
+			"criteria":      []interface{}{map[interface{}]interface{}{"check": "docker", "operator": ">", "threshold": 90, "duration": 300}},
+			"notifications": []interface{}{"ops"},
+		},
+		Checks: {
+			"id": 3, "name": "docker", "command": "docker.py --all", "interval": 30, "timeout": "10s", "enabled": true,
+			"env": map[interface{}]interface{}{"DOCKER_HOST": "unix:///var/run/docker.sock"},
+		},
+		Dashboards: {
+			"name": "CPU / Memory", "title": "CPU and memory", "views": []interface{}{"hosts"},
+			"widgets": []interface{}{map[interface{}]interface{}{"title": "CPU", "metric": "sys.cpu.pct", "position": map[interface{}]interface{}{"x": 0, "y": 0}}},
+		},
+		Views: {"name": "hosts", "title": "Hosts", "selector": "role:web", "modified_at": "2018-05-01T10:00:00Z"},
+	}
+	for resourceType, definition := range definitions {
+		t.Run(resourceType, func(t *testing.T) {
+			exported, err := marshalCanonical(resourceType, definition)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := resource{path: resourceType + "/" + getResourceFileName(resourceType, fmt.Sprint(definition["name"])), bytes: exported}
+			if errs := validateResource(res); len(errs) > 0 {
+				t.Errorf("validateResource() = %v for the export\n%s", errs, exported)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/outlyerapp/outlyer-cli/config"
+	"github.com/outlyerapp/outlyer-cli/terminal"
+	"github.com/spf13/cobra"
+)
+
+// workerPool runs jobs concurrently with a bounded number of workers, so bulk commands
+// don't send an unbounded number of concurrent requests to the Outlyer API
+type workerPool struct {
+	parallelism int
+	progress    bool
+	mutex       sync.Mutex
+}
+
+// newWorkerPool creates a workerPool with the parallelism set by the --parallelism flag
+// if the command has it, or by the 'parallelism' configuration otherwise
+func newWorkerPool(cmd *cobra.Command) *workerPool {
+	parallelism := config.CLI.GetInt("parallelism")
+	if flag := cmd.PersistentFlags().Lookup("parallelism"); flag != nil && flag.Changed {
+		parallelism, _ = cmd.PersistentFlags().GetInt("parallelism")
+	}
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	return &workerPool{parallelism: parallelism, progress: terminal.IsTerminal(os.Stderr)}
+}
+
+// run calls job for every index from 0 to count-1 with at most parallelism jobs running at the same time,
+// and waits for all of them to finish. The description returned by each job is reported as progress
+// on stderr when it is a terminal.
+func (p *workerPool) run(count int, job func(i int) string) {
+	jobs := make(chan int)
+	done := 0
+
+	workers := p.parallelism
+	if count < workers {
+		workers = count
+	}
+
+	// Creates WaitGroup to wait for workers to finish all jobs
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				description := job(i)
+
+				p.mutex.Lock()
+				done++
+				if p.progress {
+					fmt.Fprintf(os.Stderr, "\r\033[K[%d/%d] %s", done, count, description)
+				}
+				p.mutex.Unlock()
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if p.progress && count > 0 {
+		fmt.Fprint(os.Stderr, "\r\033[K") // Clears the progress line
+	}
+}
@@ -0,0 +1,52 @@
+package command
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolRun(t *testing.T) {
+	tests := []struct {
+		parallelism int
+		count       int
+	}{
+		{1, 5},
+		{3, 10},
+		{10, 3},
+		{4, 0},
+	}
+	for _, test := range tests {
+		pool := &workerPool{parallelism: test.parallelism}
+
+		var mutex sync.Mutex
+		running, maxRunning := 0, 0
+		done := make([]bool, test.count)
+
+		pool.run(test.count, func(i int) string {
+			mutex.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mutex.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mutex.Lock()
+			running--
+			done[i] = true
+			mutex.Unlock()
+			return ""
+		})
+
+		for i, ok := range done {
+			if !ok {
+				t.Errorf("workerPool.run() with parallelism %d did not run job %d", test.parallelism, i)
+			}
+		}
+		if maxRunning > test.parallelism {
+			t.Errorf("workerPool.run() ran %d jobs concurrently, want at most %d", maxRunning, test.parallelism)
+		}
+	}
+}
@@ -1,28 +1,101 @@
 package config
 
 import (
-	"fmt"
 	"os"
 	"os/user"
 
 	"github.com/spf13/viper"
 )
 
+// Environment variables overriding the settings of the configuration file and its profiles
+const (
+	// AccountEnv sets the account to use when commands are run without --account
+	AccountEnv = "OUTLYER_ACCOUNT"
+	// APITokenEnv sets the API token
+	APITokenEnv = "OUTLYER_API_TOKEN"
+	// APIURLEnv sets the URL of the Outlyer API
+	APIURLEnv = "OUTLYER_API_URL"
+	// ProfileEnv sets the profile to use
+	ProfileEnv = "OUTLYER_PROFILE"
+)
+
 // CLI stores Outlyer configurations
 var CLI = viper.New()
 
+// defaultCommonHeaders are sent with every request to the Outlyer API unless the configuration overrides them
+var defaultCommonHeaders = map[string]string{
+	"accept":     "application/yaml",
+	"user-agent": "outlyer/1.0",
+}
+
+// homeDir is the user's home directory, where the configuration file is stored
+var homeDir string
+
+// Settings are taken from, in order of precedence: command line flags, environment variables,
+// the selected profile, the top-level settings of the configuration file and the defaults below
 func init() {
-	user, err := user.Current()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "Could not read user's home directory", err)
-		os.Exit(1)
+	// Containers may run with a user that has no home directory, in which case
+	// the configuration comes from environment variables or the --config flag
+	if user, err := user.Current(); err == nil {
+		homeDir = user.HomeDir
+	} else {
+		homeDir = os.Getenv("HOME")
 	}
 
-	CLI.AddConfigPath(user.HomeDir)
+	if homeDir != "" {
+		CLI.AddConfigPath(homeDir)
+	}
 	CLI.SetConfigName(".outlyer")
-	CLI.SetDefault("headers.common.accept", "application/yaml")
-	CLI.SetDefault("headers.common.user-agent", "outlyer/1.0")
+	CLI.SetConfigType("yaml")
+	CLI.BindEnv("profile", ProfileEnv)
+	CLI.BindEnv("default-account", AccountEnv)
+	CLI.BindEnv("api-token", APITokenEnv)
+	CLI.BindEnv("api-url", APIURLEnv)
+	for header, value := range defaultCommonHeaders {
+		CLI.SetDefault("headers.common."+header, value)
+	}
 	CLI.SetDefault("headers.post.content-type", "application/yaml")
 	CLI.SetDefault("api-url", "https://api2.outlyer.com/v2")
-	CLI.ReadInConfig()
+	CLI.SetDefault("parallelism", 10)
+	CLI.SetDefault("retries", 3)
+	CLI.SetDefault("retry-wait", "1s")
+	CLI.SetDefault("max-retry-wait", "30s")
+	CLI.SetDefault("connect-timeout", "10s")
+	CLI.SetDefault("read-timeout", "60s")
+	CLI.SetDefault("timeout", "120s")
+	CLI.SetDefault("output", "table")
+}
+
+// Load reads the configuration file at the given path, or the one in the user's home directory
+// if no path is given, and applies the settings of the selected profile. A missing configuration
+// file is only an error when its path is given and mustExist is set.
+func Load(path string, mustExist bool) error {
+	if path != "" {
+		CLI.SetConfigFile(path)
+	}
+	err := CLI.ReadInConfig()
+	if _, notFound := err.(viper.ConfigFileNotFoundError); notFound {
+		err = nil
+	}
+	if os.IsNotExist(err) && (path == "" || !mustExist) {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	return LoadProfile()
+}
+
+// CommonHeaders returns the headers to send with every request to the Outlyer API. Since viper
+// doesn't merge maps from different sources, the headers set in the configuration file or
+// a profile are merged here with the default ones.
+func CommonHeaders() map[string]string {
+	headers := make(map[string]string)
+	for header, value := range defaultCommonHeaders {
+		headers[header] = value
+	}
+	for header, value := range CLI.GetStringMapString("headers.common") {
+		headers[header] = value
+	}
+	return headers
 }
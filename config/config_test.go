@@ -0,0 +1,101 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeConfigFile writes a configuration file with top-level settings and a staging profile
+func writeConfigFile(t *testing.T, dir string) string {
+	path := filepath.Join(dir, "outlyer.yaml")
+	content := `api-token: file-token
+api-url: https://file.example.com
+default-account: file-account
+profiles:
+  staging:
+    api-token: profile-token
+    default-account: profile-account
+`
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeConfigFile(t, dir)
+	defer CLI.SetConfigFile("")
+
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantToken   string
+		wantURL     string
+		wantAccount string
+	}{
+		{"configuration file", nil, "file-token", "https://file.example.com", "file-account"},
+		{"profile over the configuration file", map[string]string{ProfileEnv: "staging"},
+			"profile-token", "https://file.example.com", "profile-account"},
+		{"environment over the profile", map[string]string{ProfileEnv: "staging", APITokenEnv: "env-token", APIURLEnv: "https://env.example.com", AccountEnv: "env-account"},
+			"env-token", "https://env.example.com", "env-account"},
+		{"environment over the configuration file", map[string]string{AccountEnv: "env-account"},
+			"file-token", "https://file.example.com", "env-account"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range []string{ProfileEnv, APITokenEnv, APIURLEnv, AccountEnv} {
+				os.Unsetenv(name)
+			}
+			for name, value := range test.env {
+				os.Setenv(name, value)
+				defer os.Unsetenv(name)
+			}
+
+			if err := Load(path, true); err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got := CLI.GetString("api-token"); got != test.wantToken {
+				t.Errorf("api-token = %v, want %v", got, test.wantToken)
+			}
+			if got := CLI.GetString("api-url"); got != test.wantURL {
+				t.Errorf("api-url = %v, want %v", got, test.wantURL)
+			}
+			if got := CLI.GetString("default-account"); got != test.wantAccount {
+				t.Errorf("default-account = %v, want %v", got, test.wantAccount)
+			}
+		})
+	}
+}
+
+func TestLoadConfigPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer CLI.SetConfigFile("")
+
+	missing := filepath.Join(dir, "missing.yaml")
+	if err := Load(missing, true); err == nil {
+		t.Errorf("Load() accepted a missing configuration file that must exist")
+	}
+	if err := Load(missing, false); err != nil {
+		t.Errorf("Load() error = %v for a missing configuration file that is about to be written", err)
+	}
+	if FilePath() != missing {
+		t.Errorf("FilePath() = %v, want %v", FilePath(), missing)
+	}
+
+	os.Setenv(ProfileEnv, "prod")
+	defer os.Unsetenv(ProfileEnv)
+	if _, notFound := Load(writeConfigFile(t, dir), true).(*ProfileNotFoundError); !notFound {
+		t.Errorf("Load() didn't report the missing profile")
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// FilePath returns the path of the configuration file in use, or the default one
+// in the user's home directory if there is none yet. It returns an empty path
+// if there is no configuration file and the user has no home directory.
+func FilePath() string {
+	if path := CLI.ConfigFileUsed(); path != "" {
+		return path
+	}
+	if homeDir == "" {
+		return ""
+	}
+	return filepath.Join(homeDir, ".outlyer.yaml")
+}
+
+// ReadFile reads the settings stored in the configuration file, without the defaults or the selected
+// profile applied, so they can be updated and written back without losing any of them
+func ReadFile() (map[interface{}]interface{}, error) {
+	settings := make(map[interface{}]interface{})
+	if FilePath() == "" {
+		return settings, nil
+	}
+	settingsInBytes, err := ioutil.ReadFile(FilePath())
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(settingsInBytes, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// WriteFile writes the settings to the configuration file, which is only readable
+// and writable by the user since it contains API tokens
+func WriteFile(settings map[interface{}]interface{}) error {
+	if FilePath() == "" {
+		return fmt.Errorf("there is no home directory to store the configuration file, use --config to choose where to store it")
+	}
+	settingsInBytes, err := yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(FilePath(), settingsInBytes, 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the permissions of existing files, like ones created by older versions
+	return os.Chmod(FilePath(), 0600)
+}
+
+// SetValue sets the value of a dotted key like 'profiles.staging.api-url' in the settings,
+// creating any missing intermediate sections
+func SetValue(settings map[interface{}]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		section, ok := settings[part].(map[interface{}]interface{})
+		if !ok {
+			section = make(map[interface{}]interface{})
+			settings[part] = section
+		}
+		settings = section
+	}
+	settings[parts[len(parts)-1]] = value
+}
+
+// GetValue returns the value of a dotted key like 'profiles.staging.api-url' in the settings
+func GetValue(settings map[interface{}]interface{}, key string) (interface{}, bool) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		section, ok := settings[part].(map[interface{}]interface{})
+		if !ok {
+			return nil, false
+		}
+		settings = section
+	}
+	value, found := settings[parts[len(parts)-1]]
+	return value, found
+}
+
+// DeleteValue deletes a dotted key like 'profiles.staging.api-token' from the settings, if present
+func DeleteValue(settings map[interface{}]interface{}, key string) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		section, ok := settings[part].(map[interface{}]interface{})
+		if !ok {
+			return
+		}
+		settings = section
+	}
+	delete(settings, parts[len(parts)-1])
+}
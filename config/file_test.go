@@ -0,0 +1,83 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSetValue(t *testing.T) {
+	settings := map[interface{}]interface{}{
+		"api-token": "token",
+		"profiles":  map[interface{}]interface{}{"staging": map[interface{}]interface{}{"api-token": "staging-token"}},
+	}
+	SetValue(settings, "profiles.staging.api-url", "https://staging")
+	SetValue(settings, "profiles.prod.default-account", "acme")
+	SetValue(settings, "api-token", "new-token")
+
+	want := map[interface{}]interface{}{
+		"api-token": "new-token",
+		"profiles": map[interface{}]interface{}{
+			"staging": map[interface{}]interface{}{"api-token": "staging-token", "api-url": "https://staging"},
+			"prod":    map[interface{}]interface{}{"default-account": "acme"},
+		},
+	}
+	if !reflect.DeepEqual(settings, want) {
+		t.Errorf("SetValue() = %v, want %v", settings, want)
+	}
+
+	if value, found := GetValue(settings, "profiles.staging.api-url"); !found || value != "https://staging" {
+		t.Errorf("GetValue() = %v, %v, want https://staging, true", value, found)
+	}
+	if _, found := GetValue(settings, "profiles.dev.api-url"); found {
+		t.Errorf("GetValue() found a key of a missing profile")
+	}
+
+	DeleteValue(settings, "profiles.staging.api-token")
+	DeleteValue(settings, "profiles.dev.api-token")
+	if _, found := GetValue(settings, "profiles.staging.api-token"); found {
+		t.Errorf("DeleteValue() kept the deleted key")
+	}
+	if _, found := GetValue(settings, "profiles.dev"); found {
+		t.Errorf("DeleteValue() created a section of a missing profile")
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".outlyer.yaml")
+	if err := ioutil.WriteFile(path, []byte("api-url: https://api.example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	CLI.SetConfigFile(path)
+	defer CLI.SetConfigFile("")
+
+	settings, err := ReadFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetValue(settings, "api-token", "token")
+	if err := WriteFile(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := fileInfo.Mode().Perm(); mode != 0600 {
+		t.Errorf("WriteFile() wrote the file with mode %o, want 600", mode)
+	}
+	written, err := ReadFile()
+	want := map[interface{}]interface{}{"api-url": "https://api.example.com", "api-token": "token"}
+	if err != nil || !reflect.DeepEqual(written, want) {
+		t.Errorf("ReadFile() = %v, %v, want %v", written, err, want)
+	}
+}
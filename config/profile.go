@@ -0,0 +1,81 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ProfileNotFoundError is returned when the selected profile doesn't exist in the configuration file
+type ProfileNotFoundError struct {
+	Name string
+}
+
+func (e *ProfileNotFoundError) Error() string {
+	return fmt.Sprintf("profile '%s' not found in %s", e.Name, FilePath())
+}
+
+// ProfileName returns the name of the selected profile: the one set by the --profile flag,
+// the OUTLYER_PROFILE environment variable or the 'current-profile' configuration, in that order.
+// It returns an empty name if no profile is selected.
+func ProfileName() string {
+	if name := CLI.GetString("profile"); name != "" {
+		return name
+	}
+	return CLI.GetString("current-profile")
+}
+
+// tokenKeys are the settings the API token may be obtained from
+var tokenKeys = []string{"api-token", "encrypted-api-token", "credential-process"}
+
+// LoadProfile applies the settings of the selected profile, like its API token, API URL,
+// default account and headers, over the top-level settings of the configuration file
+func LoadProfile() error {
+	name := ProfileName()
+	if name == "" {
+		return nil
+	}
+
+	// Viper stores all keys in lower case, including the profile names
+	profile, found := CLI.GetStringMap("profiles")[strings.ToLower(name)]
+	if !found {
+		return &ProfileNotFoundError{Name: name}
+	}
+	profileInBytes, err := yaml.Marshal(withTokenKeys(profile))
+	if err != nil {
+		return err
+	}
+	return CLI.MergeConfig(bytes.NewReader(profileInBytes))
+}
+
+// withTokenKeys returns the profile settings clearing the top-level settings the API token may be obtained
+// from when the profile sets any of them, so a plaintext token in the profile isn't shadowed by a credential
+// process or an encrypted token set at the top level
+func withTokenKeys(profile interface{}) interface{} {
+	settings, ok := profile.(map[string]interface{})
+	if !ok {
+		return profile
+	}
+	setsToken := false
+	for _, key := range tokenKeys {
+		if _, found := settings[key]; found {
+			setsToken = true
+		}
+	}
+	if !setsToken {
+		return profile
+	}
+
+	merged := make(map[string]interface{}, len(settings)+len(tokenKeys))
+	for key, value := range settings {
+		merged[key] = value
+	}
+	for _, key := range tokenKeys {
+		if _, found := merged[key]; !found {
+			merged[key] = ""
+		}
+	}
+	return merged
+}
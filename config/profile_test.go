@@ -0,0 +1,43 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithTokenKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile interface{}
+		want    interface{}
+	}{
+		{
+			name:    "profile without token keeps the top-level token",
+			profile: map[string]interface{}{"api-url": "https://staging"},
+			want:    map[string]interface{}{"api-url": "https://staging"},
+		},
+		{
+			name:    "profile with plaintext token clears the other token keys",
+			profile: map[string]interface{}{"api-token": "staging-token"},
+			want:    map[string]interface{}{"api-token": "staging-token", "encrypted-api-token": "", "credential-process": ""},
+		},
+		{
+			name:    "profile with credential process clears the other token keys",
+			profile: map[string]interface{}{"credential-process": "vault read token"},
+			want:    map[string]interface{}{"api-token": "", "encrypted-api-token": "", "credential-process": "vault read token"},
+		},
+		{
+			name:    "empty profile",
+			profile: nil,
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withTokenKeys(tt.profile); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withTokenKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Package credentials provides the API token used to authenticate requests to the Outlyer API,
+// which may be stored in the configuration file, encrypted with a passphrase or obtained
+// from an external command so it is never stored in plaintext
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/outlyerapp/outlyer-cli/config"
+)
+
+// Error is returned when the API token can't be obtained
+type Error struct {
+	Source string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("could not obtain the API token from %s: %s", e.Source, e.Err)
+}
+
+// token caches the API token obtained from a credential process or the encrypted token store,
+// so the process is run and the passphrase asked at most once per command until the token expires
+var token struct {
+	sync.Mutex
+	value      string
+	expiration time.Time
+	override   string
+}
+
+// expirationSkew renews cached tokens a bit before they expire, so they don't expire mid-request
+const expirationSkew = time.Minute
+
+// Token returns the API token from, in order of precedence: the token set with SetToken, the
+// OUTLYER_API_TOKEN environment variable, the 'credential-process' command, the passphrase-encrypted
+// 'encrypted-api-token' and the plaintext 'api-token' of the selected profile or the configuration file
+func Token() (string, error) {
+	token.Lock()
+	defer token.Unlock()
+
+	if token.override != "" {
+		return token.override, nil
+	}
+	if value := os.Getenv(config.APITokenEnv); value != "" {
+		return value, nil
+	}
+	if token.value != "" && (token.expiration.IsZero() || time.Now().Add(expirationSkew).Before(token.expiration)) {
+		return token.value, nil
+	}
+
+	if command := config.CLI.GetString("credential-process"); command != "" {
+		value, expiration, err := runCredentialProcess(command)
+		if err != nil {
+			return "", &Error{Source: "credential process '" + command + "'", Err: err}
+		}
+		token.value, token.expiration = value, expiration
+		return value, nil
+	}
+
+	if encrypted := config.CLI.GetString("encrypted-api-token"); encrypted != "" {
+		passphrase, err := AskPassphrase(false)
+		if err != nil {
+			return "", &Error{Source: "the encrypted token store", Err: err}
+		}
+		value, err := Decrypt(encrypted, passphrase)
+		if err != nil {
+			return "", &Error{Source: "the encrypted token store", Err: err}
+		}
+		token.value, token.expiration = value, time.Time{}
+		return value, nil
+	}
+
+	return config.CLI.GetString("api-token"), nil
+}
+
+// SetToken makes Token return the given API token, like when validating a new token before saving it
+func SetToken(value string) {
+	token.Lock()
+	defer token.Unlock()
+	token.override = value
+}
@@ -0,0 +1,66 @@
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// processOutput is the JSON a credential process may print to set when the token expires.
+// Processes may also print just the token.
+type processOutput struct {
+	Token      string `json:"token"`
+	Expiration string `json:"expiration"`
+}
+
+// runCredentialProcess runs the command with the system shell and reads the API token from its stdout,
+// either as plain text or as JSON like '{"token": "...", "expiration": "2018-05-01T10:00:00Z"}'.
+// The command's stderr and stdin are the user's, so it can prompt for credentials.
+func runCredentialProcess(command string) (string, time.Time, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	if err := cmd.Run(); err != nil {
+		return "", time.Time{}, err
+	}
+	return parseProcessOutput(stdout.Bytes())
+}
+
+// parseProcessOutput reads the API token and its optional expiration from the output of a credential process
+func parseProcessOutput(stdout []byte) (string, time.Time, error) {
+	output := strings.TrimSpace(string(stdout))
+	if !strings.HasPrefix(output, "{") {
+		if output == "" {
+			return "", time.Time{}, fmt.Errorf("the command printed no token")
+		}
+		return output, time.Time{}, nil
+	}
+
+	var parsed processOutput
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid JSON output: %s", err)
+	}
+	if parsed.Token == "" {
+		return "", time.Time{}, fmt.Errorf("the JSON output has no 'token' field")
+	}
+	if parsed.Expiration == "" {
+		return parsed.Token, time.Time{}, nil
+	}
+	expiration, err := time.Parse(time.RFC3339, parsed.Expiration)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid expiration, it must be like '2018-05-01T10:00:00Z': %s", err)
+	}
+	return parsed.Token, expiration, nil
+}
@@ -0,0 +1,34 @@
+package credentials
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseProcessOutput(t *testing.T) {
+	tests := []struct {
+		name           string
+		output         string
+		wantToken      string
+		wantExpiration time.Time
+		wantErr        bool
+	}{
+		{"plain token", "secret\n", "secret", time.Time{}, false},
+		{"json without expiration", `{"token": "secret"}`, "secret", time.Time{}, false},
+		{"json with expiration", `{"Token": "secret", "Expiration": "2018-05-01T10:00:00Z"}`, "secret", time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC), false},
+		{"empty output", "\n", "", time.Time{}, true},
+		{"json without token", `{"expiration": "2018-05-01T10:00:00Z"}`, "", time.Time{}, true},
+		{"invalid expiration", `{"token": "secret", "expiration": "tomorrow"}`, "", time.Time{}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, expiration, err := parseProcessOutput([]byte(test.output))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("parseProcessOutput() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if token != test.wantToken || !expiration.Equal(test.wantExpiration) {
+				t.Errorf("parseProcessOutput() = %v, %v, want %v, %v", token, expiration, test.wantToken, test.wantExpiration)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+
+	"github.com/outlyerapp/outlyer-cli/terminal"
+)
+
+// PassphraseEnv is the environment variable to set the passphrase of the encrypted token store
+const PassphraseEnv = "OUTLYER_TOKEN_PASSPHRASE"
+
+// Parameters of the encrypted token store. Tokens are encrypted with AES-256-GCM using a key derived
+// from the passphrase with PBKDF2-HMAC-SHA256, and stored like 'v1:<base64 of salt, nonce and ciphertext>'.
+const (
+	storeVersion = "v1:"
+	saltSize     = 16
+	keySize      = 32
+	iterations   = 100000
+)
+
+// Encrypt encrypts the API token with the passphrase so it can be stored as 'encrypted-api-token'
+func Encrypt(value, passphrase string) (string, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := append(append(salt, nonce...), gcm.Seal(nil, nonce, []byte(value), nil)...)
+	return storeVersion + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts an API token encrypted by Encrypt with the same passphrase
+func Decrypt(encrypted, passphrase string) (string, error) {
+	if !strings.HasPrefix(encrypted, storeVersion) {
+		return "", fmt.Errorf("unsupported encrypted token format")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, storeVersion))
+	if err != nil || len(sealed) < saltSize {
+		return "", fmt.Errorf("invalid encrypted token")
+	}
+
+	salt := sealed[:saltSize]
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < saltSize+gcm.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted token")
+	}
+	nonce := sealed[saltSize : saltSize+gcm.NonceSize()]
+	value, err := gcm.Open(nil, nonce, sealed[saltSize+gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("wrong passphrase or corrupted token")
+	}
+	return string(value), nil
+}
+
+// AskPassphrase returns the passphrase set with the OUTLYER_TOKEN_PASSPHRASE environment variable,
+// or asks the user for it, twice if confirm is set so typos don't make the token unrecoverable
+func AskPassphrase(confirm bool) (string, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	if !terminal.IsTerminal(os.Stdin) {
+		return "", fmt.Errorf("stdin is not a terminal to ask for the passphrase, set it with %s", PassphraseEnv)
+	}
+
+	passphrase, err := readPassphrase("Passphrase of the encrypted API token: ")
+	if err != nil || !confirm {
+		return passphrase, err
+	}
+	confirmation, err := readPassphrase("Repeat the passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if confirmation != passphrase {
+		return "", fmt.Errorf("passphrases don't match")
+	}
+	return passphrase, nil
+}
+
+func readPassphrase(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := terminal.ReadPassword(os.Stdin)
+	fmt.Fprintln(os.Stderr, "")
+	if err != nil {
+		return "", err
+	}
+	if len(passphrase) == 0 {
+		return "", fmt.Errorf("passphrase is required")
+	}
+	return string(passphrase), nil
+}
+
+// newGCM creates the AES-GCM cipher with the key derived from the passphrase and salt
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2([]byte(passphrase), salt, iterations, keySize, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2 derives a key from the password as defined in RFC 8018, section 5.2
+func pbkdf2(password, salt []byte, iterations, keyLength int, h func() hash.Hash) []byte {
+	prf := hmac.New(h, password)
+	hashLength := prf.Size()
+	blocks := (keyLength + hashLength - 1) / hashLength
+
+	key := make([]byte, 0, blocks*hashLength)
+	u := make([]byte, hashLength)
+	for block := 1; block <= blocks; block++ {
+		// U1 = PRF(password, salt || INT(block))
+		prf.Reset()
+		prf.Write(salt)
+		var counter [4]byte
+		binary.BigEndian.PutUint32(counter[:], uint32(block))
+		prf.Write(counter[:])
+		u = prf.Sum(u[:0])
+		t := make([]byte, hashLength)
+		copy(t, u)
+
+		// T = U1 xor U2 xor ... xor Uc, where Ui = PRF(password, Ui-1)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLength]
+}
@@ -0,0 +1,48 @@
+package credentials
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestPBKDF2(t *testing.T) {
+	// Test vectors from RFC 7914, section 11
+	tests := []struct {
+		password   string
+		salt       string
+		iterations int
+		want       string
+	}{
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+	}
+	for _, test := range tests {
+		t.Run(test.password, func(t *testing.T) {
+			got := hex.EncodeToString(pbkdf2([]byte(test.password), []byte(test.salt), test.iterations, 64, sha256.New))
+			if got != test.want {
+				t.Errorf("pbkdf2() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	encrypted, err := Encrypt("secret-token", "passphrase")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	if got, err := Decrypt(encrypted, "passphrase"); err != nil || got != "secret-token" {
+		t.Errorf("Decrypt() = %v, %v, want secret-token", got, err)
+	}
+	if _, err := Decrypt(encrypted, "wrong"); err == nil {
+		t.Errorf("Decrypt() with a wrong passphrase expected an error")
+	}
+	if _, err := Decrypt("v1:AAAA", "passphrase"); err == nil {
+		t.Errorf("Decrypt() of a truncated token expected an error")
+	}
+	if other, _ := Encrypt("secret-token", "passphrase"); other == encrypted {
+		t.Errorf("Encrypt() returned the same value twice, salt and nonce must be random")
+	}
+}
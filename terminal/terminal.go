@@ -0,0 +1,65 @@
+// Package terminal provides helpers to interact with the user's terminal
+package terminal
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// IsTerminal checks whether the file is an interactive terminal rather than a pipe, a regular file or a device like /dev/null
+func IsTerminal(file *os.File) bool {
+	return isTerminal(file)
+}
+
+// ReadPassword reads a line from the terminal without echoing the typed characters,
+// so secrets like API tokens are not displayed. The line break is not included.
+func ReadPassword(file *os.File) ([]byte, error) {
+	return readPassword(file)
+}
+
+// restoreOnInterrupt restores the terminal settings with restore and exits when the user interrupts
+// the command, like with Ctrl+C, while they are changed. The returned function stops watching for interrupts.
+func restoreOnInterrupt(restore func()) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-signals:
+			restore()
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// readLine reads from the file one byte at a time until the end of the line,
+// so nothing after the line is consumed
+func readLine(file *os.File) ([]byte, error) {
+	var line []byte
+	buffer := make([]byte, 1)
+	for {
+		n, err := file.Read(buffer)
+		if n > 0 {
+			if buffer[0] == '\n' {
+				break
+			}
+			if buffer[0] != '\r' {
+				line = append(line, buffer[0])
+			}
+		}
+		if err == io.EOF && len(line) > 0 {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return line, nil
+}
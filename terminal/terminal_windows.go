@@ -0,0 +1,47 @@
+package terminal
+
+import (
+	"os"
+	"syscall"
+)
+
+// Console input modes of the Windows API, see https://docs.microsoft.com/en-us/windows/console/setconsolemode
+const (
+	enableProcessedInput = 0x1
+	enableLineInput      = 0x2
+	enableEchoInput      = 0x4
+)
+
+// setConsoleMode isn't provided by the syscall package, unlike GetConsoleMode
+var setConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+func isTerminal(file *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(file.Fd()), &mode) == nil
+}
+
+func readPassword(file *os.File) ([]byte, error) {
+	handle := syscall.Handle(file.Fd())
+	var original uint32
+	if err := syscall.GetConsoleMode(handle, &original); err != nil {
+		return nil, err
+	}
+
+	mode := original&^enableEchoInput | enableProcessedInput | enableLineInput
+	if err := setMode(handle, mode); err != nil {
+		return nil, err
+	}
+	restore := func() { setMode(handle, original) }
+	defer restore()
+	defer restoreOnInterrupt(restore)()
+
+	return readLine(file)
+}
+
+// setMode sets the input mode of the console
+func setMode(handle syscall.Handle, mode uint32) error {
+	if ok, _, err := setConsoleMode.Call(uintptr(handle), uintptr(mode)); ok == 0 {
+		return err
+	}
+	return nil
+}